@@ -2,28 +2,53 @@ package aes
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
 )
 
 type (
-	cbc struct{}
-	cfb struct{}
-	ctr struct{}
-	ecb struct{}
-	gcm struct{}
-	ofb struct{}
+	cbc    struct{}
+	cbccts struct{}
+	ccm    struct{}
+	cfb    struct{}
+	cmac   struct{}
+	ctr    struct{}
+	eax    struct{}
+	ecb    struct{}
+	gcm    struct{}
+	gcmsiv struct{}
+	ige    struct{}
+	kw     struct{}
+	ocb    struct{}
+	ofb    struct{}
+	pcbc   struct{}
+	siv    struct{}
+	xts    struct{}
 )
 
 var (
-	CBC cbc // CBC (Cipher Block Chaining): Encrypts each block of plaintext with XOR chaining to the previous ciphertext block.
-	CFB cfb // CFB (Cipher Feedback): Encrypts an IV and XORs it with plaintext segments, turning AES into a self-synchronizing stream cipher.
-	CTR ctr // CTR (Counter): Encrypts a counter value and XORs it with plaintext, effectively turning AES into a stream cipher.
-	ECB ecb // ECB (Electronic Codebook): Encrypts each block of plaintext independently.
-	GCM gcm // GCM (Galois/Counter Mode): Combines CTR mode encryption with Galois mode for authentication, providing confidentiality and integrity.
-	OFB ofb // OFB (Output Feedback): Encrypts an IV to create a keystream, XORed with plaintext to produce ciphertext, making AES a stream cipher.
+	CBC    cbc    // CBC (Cipher Block Chaining): Encrypts each block of plaintext with XOR chaining to the previous ciphertext block.
+	CBCCTS cbccts // CBC-CTS (Cipher Block Chaining with Ciphertext Stealing): Encrypts like CBC and steals ciphertext from the penultimate block so the ciphertext keeps the size of the plaintext (NIST SP 800-38A addendum).
+	CCM    ccm    // CCM (Counter with CBC-MAC): Combines CTR mode encryption with a CBC-MAC over the nonce, associated data and plaintext, providing confidentiality and integrity (NIST SP 800-38C / RFC 3610).
+	CFB    cfb    // CFB (Cipher Feedback): Encrypts an IV and XORs it with plaintext segments, turning AES into a self-synchronizing stream cipher.
+	CMAC   cmac   // CMAC (Cipher-based Message Authentication Code): Computes a message authentication code with AES in CBC-MAC fashion using derived subkeys (NIST SP 800-38B / RFC 4493).
+	CTR    ctr    // CTR (Counter): Encrypts a counter value and XORs it with plaintext, effectively turning AES into a stream cipher.
+	EAX    eax    // EAX: Combines CTR mode encryption with OMAC over the nonce, associated data and ciphertext, providing confidentiality and integrity with nonces of any size.
+	ECB    ecb    // ECB (Electronic Codebook): Encrypts each block of plaintext independently.
+	GCM    gcm    // GCM (Galois/Counter Mode): Combines CTR mode encryption with Galois mode for authentication, providing confidentiality and integrity.
+	GCMSIV gcmsiv // GCM-SIV (Galois/Counter Mode with Synthetic IV): Derives per-nonce keys and an IV from POLYVAL over the data, remaining secure when a nonce is reused (RFC 8452).
+	IGE    ige    // IGE (Infinite Garble Extension): Encrypts each block of plaintext with XOR chaining to both the previous ciphertext and plaintext blocks, propagating errors in both directions.
+	KW     kw     // KW (Key Wrap): Wraps key material under a key-encryption key with an integrity check value, optionally padding keys of any size (RFC 3394 / RFC 5649).
+	OCB    ocb    // OCB (Offset Codebook): Encrypts each block of plaintext with a per-block offset and authenticates with a checksum in a single pass, providing confidentiality and integrity (RFC 7253).
+	OFB    ofb    // OFB (Output Feedback): Encrypts an IV to create a keystream, XORed with plaintext to produce ciphertext, making AES a stream cipher.
+	PCBC   pcbc   // PCBC (Propagating Cipher Block Chaining): Encrypts each block of plaintext with XOR chaining to both the previous plaintext and ciphertext blocks, propagating errors to all following blocks.
+	SIV    siv    // SIV (Synthetic Initialization Vector): Derives the IV from a CMAC-based S2V over the associated data and plaintext, providing deterministic authenticated encryption (RFC 5297).
+	XTS    xts    // XTS (XEX-based Tweaked CodeBook with ciphertext Stealing): Encrypts each sector with a tweak derived from its number, preserving its length for disk encryption (IEEE 1619).
 )
 
+var ErrAuthentication = errors.New("aes: message authentication failed")
+
 type (
 	BlockSizeError         int
 	EmptyDataError         int
@@ -72,6 +97,44 @@ func GenerateRandomBytes(size int) ([]byte, error) {
 	return b, nil
 }
 
+// appendRandom appends size bytes read from r to dst, r being crypto/rand.Reader when nil.
+func appendRandom(dst []byte, r io.Reader, size int) ([]byte, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	ret, tail := sliceForAppend(dst, size)
+	if _, err := io.ReadFull(r, tail); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// splitPrefix splits the IV or nonce of size bytes prefixed to ciphertext.
+func splitPrefix(ciphertext []byte, size int) (prefix, rest []byte, err error) {
+	lenCt := len(ciphertext)
+	if lenCt < size {
+		return nil, nil, InvalidCiphertextError(lenCt)
+	}
+	if lenCt == size {
+		return nil, nil, InvalidCiphertextError(0)
+	}
+	return ciphertext[:size], ciphertext[size:], nil
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
+
 func ValidBlockSize(length int) error {
 	switch length {
 	case 16, 24, 32:
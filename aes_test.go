@@ -0,0 +1,16 @@
+package aes
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// fromHex decodes a hex test vector, failing the test on malformed input.
+func fromHex(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
@@ -0,0 +1,160 @@
+package aes
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/colduction/aes/padding"
+)
+
+type autoFuncs struct {
+	name       string
+	prefix     int
+	encryptIV  func(input, iv []byte) ([]byte, error)
+	encrypt    func(rand io.Reader, input []byte) ([]byte, error)
+	decrypt    func(ciphertext []byte) ([]byte, error)
+	decryptMin int // the shortest ciphertext after the prefix accepted by decrypt
+}
+
+func autoModes(key []byte) []autoFuncs {
+	ad := []byte("additional data")
+	return []autoFuncs{
+		{
+			"CBC", 16,
+			func(in, iv []byte) ([]byte, error) { return CBC.Encrypt(in, key, iv, padding.PKCS7) },
+			func(r io.Reader, in []byte) ([]byte, error) { return CBC.EncryptAuto(r, in, key, padding.PKCS7) },
+			func(ct []byte) ([]byte, error) { return CBC.DecryptAuto(ct, key, padding.PKCS7) },
+			16,
+		},
+		{
+			"CFB", 16,
+			func(in, iv []byte) ([]byte, error) { return CFB.Encrypt(in, key, iv, nil) },
+			func(r io.Reader, in []byte) ([]byte, error) { return CFB.EncryptAuto(r, in, key, nil) },
+			func(ct []byte) ([]byte, error) { return CFB.DecryptAuto(ct, key, nil) },
+			1,
+		},
+		{
+			"CTR", 16,
+			func(in, iv []byte) ([]byte, error) { return CTR.Encrypt(in, key, iv, nil) },
+			func(r io.Reader, in []byte) ([]byte, error) { return CTR.EncryptAuto(r, in, key, nil) },
+			func(ct []byte) ([]byte, error) { return CTR.DecryptAuto(ct, key, nil) },
+			1,
+		},
+		{
+			"OFB", 16,
+			func(in, iv []byte) ([]byte, error) { return OFB.Encrypt(in, key, iv, padding.X923) },
+			func(r io.Reader, in []byte) ([]byte, error) { return OFB.EncryptAuto(r, in, key, padding.X923) },
+			func(ct []byte) ([]byte, error) { return OFB.DecryptAuto(ct, key, padding.X923) },
+			16,
+		},
+		{
+			"GCM", 12,
+			func(in, iv []byte) ([]byte, error) { return GCM.Encrypt(in, key, iv, ad, nil) },
+			func(r io.Reader, in []byte) ([]byte, error) { return GCM.EncryptAuto(r, in, key, ad, nil) },
+			func(ct []byte) ([]byte, error) { return GCM.DecryptAuto(ct, key, ad, nil) },
+			17,
+		},
+	}
+}
+
+func TestEncryptAuto(t *testing.T) {
+	key := bytes.Repeat([]byte{6}, 16)
+	iv := fromHex(t, "000102030405060708090a0b0c0d0e0f")
+	msg := []byte("the IV is read from the given reader")
+	for _, m := range autoModes(key) {
+		ct, err := m.encrypt(bytes.NewReader(iv), msg)
+		if err != nil {
+			t.Fatalf("%s: %v", m.name, err)
+		}
+		want, err := m.encryptIV(msg, iv[:m.prefix])
+		if err != nil {
+			t.Fatalf("%s: %v", m.name, err)
+		}
+		if !bytes.Equal(ct[:m.prefix], iv[:m.prefix]) || !bytes.Equal(ct[m.prefix:], want) {
+			t.Errorf("%s: got %x, want %x followed by %x", m.name, ct, iv[:m.prefix], want)
+		}
+		if pt, err := m.decrypt(ct); err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%s: got %x, %v, want %x", m.name, pt, err, msg)
+		}
+		if _, err := m.encrypt(bytes.NewReader(iv[:m.prefix-1]), msg); err != io.ErrUnexpectedEOF {
+			t.Errorf("%s: short reader: got %v, want %v", m.name, err, io.ErrUnexpectedEOF)
+		}
+		if _, err := m.encrypt(bytes.NewReader(nil), msg); err != io.EOF {
+			t.Errorf("%s: empty reader: got %v, want %v", m.name, err, io.EOF)
+		}
+		if _, err := m.encrypt(nil, nil); err != InvalidDataError(0) {
+			t.Errorf("%s: empty input: got %v, want %v", m.name, err, InvalidDataError(0))
+		}
+	}
+}
+
+func TestEncryptAutoCryptoRand(t *testing.T) {
+	key := bytes.Repeat([]byte{6}, 32)
+	msg := []byte("the IV is read from crypto/rand")
+	for _, m := range autoModes(key) {
+		a, err := m.encrypt(nil, msg)
+		if err != nil {
+			t.Fatalf("%s: %v", m.name, err)
+		}
+		b, err := m.encrypt(nil, msg)
+		if err != nil {
+			t.Fatalf("%s: %v", m.name, err)
+		}
+		if bytes.Equal(a[:m.prefix], b[:m.prefix]) {
+			t.Errorf("%s: the same IV %x was drawn twice", m.name, a[:m.prefix])
+		}
+		for _, ct := range [][]byte{a, b} {
+			if pt, err := m.decrypt(ct); err != nil || !bytes.Equal(pt, msg) {
+				t.Errorf("%s: got %x, %v, want %x", m.name, pt, err, msg)
+			}
+		}
+	}
+}
+
+func TestDecryptAutoTruncated(t *testing.T) {
+	key := bytes.Repeat([]byte{6}, 16)
+	iv := bytes.Repeat([]byte{9}, 16)
+	msg := []byte("truncated after the IV")
+	for _, m := range autoModes(key) {
+		ct, err := m.encrypt(bytes.NewReader(iv), msg)
+		if err != nil {
+			t.Fatalf("%s: %v", m.name, err)
+		}
+		for n := 0; n < m.prefix; n++ {
+			if _, err := m.decrypt(ct[:n]); err != InvalidCiphertextError(n) {
+				t.Errorf("%s: %d bytes: got %v, want %v", m.name, n, err, InvalidCiphertextError(n))
+			}
+		}
+		if _, err := m.decrypt(ct[:m.prefix]); err != InvalidCiphertextError(0) {
+			t.Errorf("%s: IV only: got %v, want %v", m.name, err, InvalidCiphertextError(0))
+		}
+		for n := m.prefix + 1; n < m.prefix+m.decryptMin; n++ {
+			if pt, err := m.decrypt(ct[:n]); err == nil {
+				t.Errorf("%s: %d bytes: got %x", m.name, n, pt)
+			}
+		}
+	}
+}
+
+func TestGCMEncryptAutoDst(t *testing.T) {
+	key := bytes.Repeat([]byte{6}, 16)
+	nonce := bytes.Repeat([]byte{9}, 12)
+	msg := []byte("appended to dst")
+	dst := []byte("prefix")
+	ct, err := GCM.EncryptAuto(bytes.NewReader(nonce), msg, key, nil, nil, dst...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := GCM.Encrypt(msg, key, nonce, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ct[len(dst):]; !bytes.HasPrefix(ct, dst) || !bytes.Equal(got[:12], nonce) || !bytes.Equal(got[12:], want) {
+		t.Errorf("got %x, want %x%x%x", ct, dst, nonce, want)
+	}
+	pt, err := GCM.DecryptAuto(ct[len(dst):], key, nil, nil, dst...)
+	if err != nil || !bytes.Equal(pt, append(bytes.Clone(dst), msg...)) {
+		t.Errorf("got %q, %v, want %q", pt, err, append(bytes.Clone(dst), msg...))
+	}
+}
@@ -3,21 +3,36 @@ package aes
 import (
 	stdaes "crypto/aes"
 	"crypto/cipher"
+	"crypto/subtle"
+	"io"
 
 	"github.com/colduction/aes/padding"
 )
 
 // Encrypts input using AES in CBC mode
 func (cbc) Encrypt(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
-	lenInput := len(input)
-	if lenInput == 0 {
+	return CBC.EncryptWithBlockSize(input, key, iv, stdaes.BlockSize, pad)
+}
+
+// Encrypts input using Rijndael in CBC mode with custom block size (16, 24 or 32)
+func (cbc) EncryptWithBlockSize(input, key, iv []byte, blocksize int, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
 		return nil, InvalidDataError(lenInput)
 	}
-	block, err := stdaes.NewCipher(key)
+	block, err := NewRijndael(key, blocksize)
 	if err != nil {
 		return nil, err
 	}
-	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+	return cbcEncrypt(block, input, iv, pad)
+}
+
+func cbcEncrypt(block cipher.Block, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	err := IvSizeEquality(len(iv), block.BlockSize())
+	if err != nil {
 		return nil, err
 	}
 	if pad != nil {
@@ -35,20 +50,74 @@ func (cbc) Encrypt(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
 	return ct, nil
 }
 
+// Encrypts input using AES in CBC mode and appends the ciphertext to dst, without allocating
+// the output when dst has room for input and a block more,
+// the key schedule is still allocated on every call, use Cipher to avoid it
+func (cbc) EncryptTo(dst, input, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cbcEncryptTo(block, dst, input, iv, pad)
+}
+
+func cbcEncryptTo(block cipher.Block, dst, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	bs := block.BlockSize()
+	if err := IvSizeEquality(len(iv), bs); err != nil {
+		return nil, err
+	}
+	ret, err := appendPadded(dst, input, pad, bs)
+	if err != nil {
+		return nil, err
+	}
+	ct := ret[len(dst):]
+	if len(ct)%bs != 0 {
+		return nil, InvalidDataError(len(ct))
+	}
+	prev := iv
+	for i := 0; i < len(ct); i += bs {
+		b := ct[i : i+bs]
+		subtle.XORBytes(b, b, prev)
+		block.Encrypt(b, b)
+		prev = b
+	}
+	return ret, nil
+}
+
 // Decrypts ciphertext using AES in CBC mode
 func (cbc) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
-	lenCt := len(ciphertext)
-	if lenCt == 0 {
+	return CBC.DecryptWithBlockSize(ciphertext, key, iv, stdaes.BlockSize, pad)
+}
+
+// Decrypts ciphertext using Rijndael in CBC mode with custom block size (16, 24 or 32)
+func (cbc) DecryptWithBlockSize(ciphertext, key, iv []byte, blocksize int, pad padding.Padding) ([]byte, error) {
+	if lenCt := len(ciphertext); lenCt == 0 {
 		return nil, InvalidCiphertextError(lenCt)
 	}
-	block, err := stdaes.NewCipher(key)
+	block, err := NewRijndael(key, blocksize)
 	if err != nil {
 		return nil, err
 	}
+	return cbcDecrypt(block, ciphertext, iv, pad)
+}
+
+func cbcDecrypt(block cipher.Block, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
 	if lenCt%block.BlockSize() != 0 {
 		return nil, InvalidDataError(lenCt)
 	}
-	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+	err := IvSizeEquality(len(iv), block.BlockSize())
+	if err != nil {
 		return nil, err
 	}
 	mode := cipher.NewCBCDecrypter(block, iv)
@@ -62,3 +131,135 @@ func (cbc) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, err
 	}
 	return pt, nil
 }
+
+// Decrypts ciphertext using AES in CBC mode and appends the plaintext to dst, without allocating
+// the output when dst has room for ciphertext,
+// the key schedule is still allocated on every call, use Cipher to avoid it
+func (cbc) DecryptTo(dst, ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	if lenCt := len(ciphertext); lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cbcDecryptTo(block, dst, ciphertext, iv, pad)
+}
+
+func cbcDecryptTo(block cipher.Block, dst, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	bs := block.BlockSize()
+	if lenCt%bs != 0 {
+		return nil, InvalidDataError(lenCt)
+	}
+	if err := IvSizeEquality(len(iv), bs); err != nil {
+		return nil, err
+	}
+	ret := append(dst, ciphertext...)
+	pt := ret[len(dst):]
+	// Going backwards keeps the previous ciphertext block available in place.
+	for i := lenCt - bs; i >= 0; i -= bs {
+		prev := iv
+		if i > 0 {
+			prev = pt[i-bs : i]
+		}
+		block.Decrypt(pt[i:i+bs], pt[i:i+bs])
+		subtle.XORBytes(pt[i:i+bs], pt[i:i+bs], prev)
+	}
+	return unpadAppended(ret, len(dst), pad, bs)
+}
+
+// Decrypts ciphertext using AES in CBC mode across workers goroutines, GOMAXPROCS when workers <= 0
+func (cbc) DecryptParallel(ciphertext, key, iv []byte, workers int, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	if lenCt%bs != 0 {
+		return nil, InvalidDataError(lenCt)
+	}
+	if err = IvSizeEquality(len(iv), bs); err != nil {
+		return nil, err
+	}
+	pt := make([]byte, lenCt)
+	parallelize(lenCt, bs, workers, func(start, end int) {
+		// Each chunk is chained to the last ciphertext block of the previous one.
+		prev := iv
+		if start > 0 {
+			prev = ciphertext[start-bs : start]
+		}
+		cipher.NewCBCDecrypter(block, prev).CryptBlocks(pt[start:end], ciphertext[start:end])
+	})
+	if pad != nil {
+		pt, err = pad.Unpad(pt, bs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pt, nil
+}
+
+// Returns a writer encrypting to w using AES in CBC mode, the padding is applied on Close which does not close w
+func (cbc) NewEncryptWriter(w io.Writer, key, iv []byte, pad padding.Padding) (io.WriteCloser, error) {
+	if err := validStreamPadding(pad); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	return newEncryptWriter(w, cipher.NewCBCEncrypter(block, iv), nil, block.BlockSize(), pad), nil
+}
+
+// Returns a reader decrypting from r using AES in CBC mode, the padding is removed from the last block at EOF
+func (cbc) NewDecryptReader(r io.Reader, key, iv []byte, pad padding.Padding) (io.Reader, error) {
+	if err := validStreamPadding(pad); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	return newDecryptReader(r, cipher.NewCBCDecrypter(block, iv), nil, block.BlockSize(), pad), nil
+}
+
+// Encrypts input using AES in CBC mode under a random IV read from rand, crypto/rand.Reader when nil,
+// and returns the IV followed by the ciphertext
+func (cbc) EncryptAuto(rand io.Reader, input, key []byte, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	ret, err := appendRandom(make([]byte, 0, len(input)+2*bs), rand, bs)
+	if err != nil {
+		return nil, err
+	}
+	return cbcEncryptTo(block, ret, input, ret[:bs], pad)
+}
+
+// Decrypts ciphertext made of the IV followed by the ciphertext using AES in CBC mode, as returned by EncryptAuto
+func (cbc) DecryptAuto(ciphertext, key []byte, pad padding.Padding) ([]byte, error) {
+	iv, rest, err := splitPrefix(ciphertext, stdaes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	return CBC.DecryptTo(nil, rest, key, iv, pad)
+}
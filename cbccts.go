@@ -0,0 +1,126 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+)
+
+// CTSVariant selects the ordering of the last two ciphertext blocks (NIST SP 800-38A addendum).
+type CTSVariant int
+
+const (
+	CS1 CTSVariant = iota + 1 // CS1: the partial penultimate block is followed by the last block.
+	CS2                       // CS2: the last two blocks are swapped only when the last plaintext block is partial.
+	CS3                       // CS3: the last two blocks are always swapped, as in Kerberos (RFC 3962).
+)
+
+type CTSVariantError int
+
+func (i CTSVariantError) Error() string {
+	return fmt.Sprintf("aes-cbc-cts: unknown ciphertext stealing variant %d", int(i))
+}
+
+func (cbccts) ValidVariant(variant CTSVariant) error {
+	switch variant {
+	case CS1, CS2, CS3:
+		return nil
+	}
+	return CTSVariantError(variant)
+}
+
+// ctsSwapped reports whether the last two ciphertext blocks are swapped for a last plaintext block of d bytes.
+func ctsSwapped(variant CTSVariant, d, blocksize int) bool {
+	return variant == CS3 || (variant == CS2 && d != blocksize)
+}
+
+// Encrypts input of at least one block using AES in CBC mode with ciphertext stealing, the ciphertext has the same size as input
+func (cbccts) Encrypt(input, key, iv []byte, variant CTSVariant) ([]byte, error) {
+	err := CBCCTS.ValidVariant(variant)
+	if err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	lenInput := len(input)
+	if lenInput < bs {
+		return nil, InvalidDataError(lenInput)
+	}
+	if err = IvSizeEquality(len(iv), bs); err != nil {
+		return nil, err
+	}
+	ct := make([]byte, lenInput)
+	if lenInput == bs {
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ct, input)
+		return ct, nil
+	}
+	d := lenInput % bs
+	if d == 0 {
+		d = bs
+	}
+	head := lenInput - d - bs
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ct[:lenInput-d], input[:lenInput-d])
+	prev := make([]byte, bs)
+	copy(prev, ct[head:lenInput-d])
+	last := make([]byte, bs)
+	copy(last, input[lenInput-d:])
+	subtle.XORBytes(last, last, prev)
+	block.Encrypt(last, last)
+	if ctsSwapped(variant, d, bs) {
+		copy(ct[head:], last)
+		copy(ct[head+bs:], prev[:d])
+	} else {
+		copy(ct[head:], prev[:d])
+		copy(ct[head+d:], last)
+	}
+	return ct, nil
+}
+
+// Decrypts ciphertext of at least one block using AES in CBC mode with ciphertext stealing
+func (cbccts) Decrypt(ciphertext, key, iv []byte, variant CTSVariant) ([]byte, error) {
+	err := CBCCTS.ValidVariant(variant)
+	if err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	lenCt := len(ciphertext)
+	if lenCt < bs {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	if err = IvSizeEquality(len(iv), bs); err != nil {
+		return nil, err
+	}
+	pt := make([]byte, lenCt)
+	if lenCt == bs {
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(pt, ciphertext)
+		return pt, nil
+	}
+	d := lenCt % bs
+	if d == 0 {
+		d = bs
+	}
+	head := lenCt - d - bs
+	var prevStar, last []byte
+	if ctsSwapped(variant, d, bs) {
+		last, prevStar = ciphertext[head:head+bs], ciphertext[head+bs:]
+	} else {
+		prevStar, last = ciphertext[head:head+d], ciphertext[head+d:]
+	}
+	// Decrypting the last block recovers the stolen tail of the penultimate ciphertext block.
+	z := make([]byte, bs)
+	block.Decrypt(z, last)
+	copy(pt[head:], prevStar)
+	copy(pt[head+d:], z[d:])
+	subtle.XORBytes(pt[lenCt-d:], z[:d], prevStar)
+	copy(pt[:head], ciphertext[:head])
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(pt[:head+bs], pt[:head+bs])
+	return pt, nil
+}
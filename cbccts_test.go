@@ -0,0 +1,94 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+// RFC 3962 appendix B, AES-128 with a zero IV. Kerberos uses the CS3 variant.
+const ctsMessage = "I would like the General Gau's Chicken, please, and wonton soup."
+
+var ctsTests = []struct {
+	n          int
+	ciphertext string
+}{
+	{17, "c6353568f2bf8cb4d8a580362da7ff7f97"},
+	{31, "fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5"},
+	{32, "39312523a78662d5be7fcbcc98ebf5a897687268d6ecccc0c07b25e25ecfe584"},
+	{47, "97687268d6ecccc0c07b25e25ecfe584b3fffd940c16a18c1b5549d2f838029e39312523a78662d5be7fcbcc98ebf5"},
+	{48, "97687268d6ecccc0c07b25e25ecfe5849dad8bbb96c4cdc03bc103e1a194bbd839312523a78662d5be7fcbcc98ebf5a8"},
+	{64, "97687268d6ecccc0c07b25e25ecfe58439312523a78662d5be7fcbcc98ebf5a84807efe836ee89a526730dbc2f7bc8409dad8bbb96c4cdc03bc103e1a194bbd8"},
+}
+
+// ctsReorder turns a CS3 ciphertext into the CS1 or CS2 layout.
+func ctsReorder(cs3 []byte, variant CTSVariant) []byte {
+	n := len(cs3)
+	d := n % 16
+	if d == 0 {
+		d = 16
+	}
+	if n == 16 || variant == CS3 || (variant == CS2 && d != 16) {
+		return cs3
+	}
+	// CS3 ends with the last full block followed by the d bytes of the penultimate one.
+	out := bytes.Clone(cs3[:n-16-d])
+	out = append(out, cs3[n-d:]...)
+	return append(out, cs3[n-16-d:n-d]...)
+}
+
+func TestCBCCTSVectors(t *testing.T) {
+	key := fromHex(t, "636869636b656e207465726979616b69")
+	iv := make([]byte, 16)
+	for _, tt := range ctsTests {
+		msg := []byte(ctsMessage[:tt.n])
+		for _, variant := range []CTSVariant{CS1, CS2, CS3} {
+			want := ctsReorder(fromHex(t, tt.ciphertext), variant)
+			ct, err := CBCCTS.Encrypt(msg, key, iv, variant)
+			if err != nil {
+				t.Fatalf("CS%d, %d bytes: %v", variant, tt.n, err)
+			}
+			if !bytes.Equal(ct, want) {
+				t.Errorf("CS%d, %d bytes: got %x, want %x", variant, tt.n, ct, want)
+			}
+			pt, err := CBCCTS.Decrypt(ct, key, iv, variant)
+			if err != nil || !bytes.Equal(pt, msg) {
+				t.Errorf("CS%d, %d bytes: decrypt got %q, %v", variant, tt.n, pt, err)
+			}
+		}
+	}
+}
+
+func TestCBCCTSSizes(t *testing.T) {
+	key := fromHex(t, "636869636b656e207465726979616b69")
+	iv := make([]byte, 16)
+	for _, variant := range []CTSVariant{CS1, CS2, CS3} {
+		// A single block is plain CBC for every variant.
+		msg := []byte(ctsMessage[:16])
+		cbc, _ := CBC.Encrypt(msg, key, iv, nil)
+		ct, err := CBCCTS.Encrypt(msg, key, iv, variant)
+		if err != nil || !bytes.Equal(ct, cbc) {
+			t.Errorf("CS%d, one block: got %x, want %x (%v)", variant, ct, cbc, err)
+		}
+		pt, err := CBCCTS.Decrypt(ct, key, iv, variant)
+		if err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("CS%d, one block: decrypt got %q, %v", variant, pt, err)
+		}
+		for n := 17; n <= len(ctsMessage); n++ {
+			msg := []byte(ctsMessage[:n])
+			ct, err := CBCCTS.Encrypt(msg, key, iv, variant)
+			if err != nil || len(ct) != n {
+				t.Fatalf("CS%d, %d bytes: got %d bytes, %v", variant, n, len(ct), err)
+			}
+			pt, err := CBCCTS.Decrypt(ct, key, iv, variant)
+			if err != nil || !bytes.Equal(pt, msg) {
+				t.Errorf("CS%d, %d bytes: decrypt got %q, %v", variant, n, pt, err)
+			}
+		}
+		if _, err := CBCCTS.Encrypt([]byte(ctsMessage[:15]), key, iv, variant); err != InvalidDataError(15) {
+			t.Errorf("CS%d, short input got %v", variant, err)
+		}
+	}
+	if _, err := CBCCTS.Encrypt([]byte(ctsMessage), key, iv, 4); err != CTSVariantError(4) {
+		t.Errorf("unknown variant got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/colduction/aes/padding"
+)
+
+const (
+	ccmBlockSize    int = 16
+	ccmMinNonceSize int = 7
+	ccmMaxNonceSize int = 13
+	ccmMinTagSize   int = 4
+	ccmMaxTagSize   int = ccmBlockSize
+)
+
+type (
+	CCMDataSizeError  int
+	CCMNonceSizeError int
+	CCMTagSizeError   int
+)
+
+func (i CCMDataSizeError) Error() string {
+	return fmt.Sprintf("aes-ccm: invalid data size %d", int(i))
+}
+
+func (i CCMNonceSizeError) Error() string {
+	return fmt.Sprintf("aes-ccm: invalid nonce size %d, sizes between 7 and 13 bytes are allowed", int(i))
+}
+
+func (i CCMTagSizeError) Error() string {
+	return fmt.Sprintf("aes-ccm: incorrect tag size %d, even sizes between 4 and 16 bytes are allowed", int(i))
+}
+
+func (ccm) ValidNonceSize(length int) error {
+	if length < ccmMinNonceSize || length > ccmMaxNonceSize {
+		return CCMNonceSizeError(length)
+	}
+	return nil
+}
+
+func (ccm) ValidTagSize(length int) error {
+	if length < ccmMinTagSize || length > ccmMaxTagSize || length%2 != 0 {
+		return CCMTagSizeError(length)
+	}
+	return nil
+}
+
+// ValidDataSize checks that length fits in the 15-nonceSize bytes length field of the first block.
+func (ccm) ValidDataSize(length, nonceSize int) error {
+	if q := ccmBlockSize - 1 - nonceSize; q < 8 && uint64(length) >= 1<<(8*q) {
+		return CCMDataSizeError(length)
+	}
+	return nil
+}
+
+// ccmCBCMAC is the CBC-MAC of CCM, absorbing data that is zero padded on flush.
+type ccmCBCMAC struct {
+	block cipher.Block
+	x     [ccmBlockSize]byte
+	off   int
+}
+
+func (m *ccmCBCMAC) write(p []byte) {
+	for len(p) > 0 {
+		n := subtle.XORBytes(m.x[m.off:], m.x[m.off:], p)
+		m.off += n
+		p = p[n:]
+		if m.off == ccmBlockSize {
+			m.block.Encrypt(m.x[:], m.x[:])
+			m.off = 0
+		}
+	}
+}
+
+func (m *ccmCBCMAC) flush() {
+	if m.off > 0 {
+		m.block.Encrypt(m.x[:], m.x[:])
+		m.off = 0
+	}
+}
+
+// ccmTag computes the encrypted authentication tag into tag and returns it truncated to tagSize.
+func ccmTag(tag *[ccmBlockSize]byte, block cipher.Block, nonce, plaintext, additionalData []byte, tagSize int) []byte {
+	q := ccmBlockSize - 1 - len(nonce)
+	m := ccmCBCMAC{block: block}
+	var b [ccmBlockSize]byte
+	b[0] = byte((tagSize-2)/2<<3 | (q - 1))
+	if len(additionalData) > 0 {
+		b[0] |= 0x40
+	}
+	copy(b[1:], nonce)
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(plaintext)))
+	copy(b[1+len(nonce):], length[8-q:])
+	m.write(b[:])
+	if lenAd := uint64(len(additionalData)); lenAd > 0 {
+		switch {
+		case lenAd < 0xff00:
+			m.write(binary.BigEndian.AppendUint16(b[:0], uint16(lenAd)))
+		case lenAd <= 0xffffffff:
+			m.write(binary.BigEndian.AppendUint32(append(b[:0], 0xff, 0xfe), uint32(lenAd)))
+		default:
+			m.write(binary.BigEndian.AppendUint64(append(b[:0], 0xff, 0xff), lenAd))
+		}
+		m.write(additionalData)
+		m.flush()
+	}
+	m.write(plaintext)
+	m.flush()
+	// The tag is encrypted with the keystream block of counter 0.
+	ccmCounter(&b, nonce, 0)
+	block.Encrypt(b[:], b[:])
+	subtle.XORBytes(tag[:], m.x[:], b[:])
+	return tag[:tagSize]
+}
+
+// ccmCounter sets b to the counter block A_i for the nonce.
+func ccmCounter(b *[ccmBlockSize]byte, nonce []byte, i uint64) {
+	q := ccmBlockSize - 1 - len(nonce)
+	*b = [ccmBlockSize]byte{}
+	b[0] = byte(q - 1)
+	copy(b[1:], nonce)
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], i)
+	copy(b[1+len(nonce):], counter[8-q:])
+}
+
+// ccmCTR XORs src with the keystream starting at counter block A_1.
+func ccmCTR(block cipher.Block, nonce, dst, src []byte) {
+	var a1 [ccmBlockSize]byte
+	ccmCounter(&a1, nonce, 1)
+	cipher.NewCTR(block, a1[:]).XORKeyStream(dst, src)
+}
+
+// Encrypts input using AES in CCM mode with default tag size (16), the nonce size may be between 7 and 13 bytes
+func (ccm) Encrypt(input, key, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	return CCM.EncryptWithTagSize(input, key, nonce, additionalData, ccmMaxTagSize, pad, dst...)
+}
+
+// Encrypts input using AES in CCM mode with custom tag size, the nonce size may be between 7 and 13 bytes
+func (ccm) EncryptWithTagSize(input, key, nonce, additionalData []byte, tagSize int, pad padding.Padding, dst ...byte) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	err := CCM.ValidNonceSize(len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	if err = CCM.ValidTagSize(tagSize); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if pad != nil {
+		if input, err = pad.Pad(input, block.BlockSize()); err != nil {
+			return nil, err
+		}
+		lenInput = len(input)
+	}
+	if err = CCM.ValidDataSize(lenInput, len(nonce)); err != nil {
+		return nil, err
+	}
+	var tag [ccmBlockSize]byte
+	ret, out := sliceForAppend(dst, lenInput+tagSize)
+	copy(out[lenInput:], ccmTag(&tag, block, nonce, input, additionalData, tagSize))
+	ccmCTR(block, nonce, out, input)
+	return ret, nil
+}
+
+// Decrypts ciphertext using AES in CCM mode with default tag size (16)
+func (ccm) Decrypt(ciphertext, key, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	return CCM.DecryptWithTagSize(ciphertext, key, nonce, additionalData, ccmMaxTagSize, pad, dst...)
+}
+
+// Decrypts ciphertext using AES in CCM mode with custom tag size
+func (ccm) DecryptWithTagSize(ciphertext, key, nonce, additionalData []byte, tagSize int, pad padding.Padding, dst ...byte) ([]byte, error) {
+	err := CCM.ValidNonceSize(len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	if err = CCM.ValidTagSize(tagSize); err != nil {
+		return nil, err
+	}
+	lenCt := len(ciphertext)
+	if lenCt < tagSize {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	if err = CCM.ValidDataSize(lenCt-tagSize, len(nonce)); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var expected [ccmBlockSize]byte
+	ret, pt := sliceForAppend(dst, lenCt-tagSize)
+	ccmCTR(block, nonce, pt, ciphertext[:lenCt-tagSize])
+	if subtle.ConstantTimeCompare(ccmTag(&expected, block, nonce, pt, additionalData, tagSize), ciphertext[lenCt-tagSize:]) != 1 {
+		clear(pt)
+		return nil, ErrAuthentication
+	}
+	if pad != nil {
+		if pt, err = pad.Unpad(pt, block.BlockSize()); err != nil {
+			return nil, err
+		}
+		ret = append(ret[:len(dst)], pt...)
+	}
+	return ret, nil
+}
@@ -0,0 +1,72 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+// RFC 3610 packet vector #1 and SP 800-38C appendix C example 1.
+var ccmTests = []struct {
+	key, nonce, ad, plaintext, ciphertext string
+	tagSize                               int
+}{
+	{
+		"c0c1c2c3c4c5c6c7c8c9cacbcccdcecf", "00000003020100a0a1a2a3a4a5", "0001020304050607",
+		"08090a0b0c0d0e0f101112131415161718191a1b1c1d1e",
+		"588c979a61c663d2f066d0c2c0f989806d5f6b61dac38417e8d12cfdf926e0", 8,
+	},
+	{
+		"404142434445464748494a4b4c4d4e4f", "10111213141516", "0001020304050607",
+		"20212223", "7162015b4dac255d", 4,
+	},
+}
+
+func TestCCMVectors(t *testing.T) {
+	for i, tt := range ccmTests {
+		key, nonce, ad := fromHex(t, tt.key), fromHex(t, tt.nonce), fromHex(t, tt.ad)
+		pt, want := fromHex(t, tt.plaintext), fromHex(t, tt.ciphertext)
+		ct, err := CCM.EncryptWithTagSize(pt, key, nonce, ad, tt.tagSize, nil)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !bytes.Equal(ct, want) {
+			t.Errorf("#%d: got %x, want %x", i, ct, want)
+		}
+		got, err := CCM.DecryptWithTagSize(ct, key, nonce, ad, tt.tagSize, nil)
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("#%d: decrypt got %x, %v", i, got, err)
+		}
+		ct[len(ct)-1] ^= 1
+		if _, err := CCM.DecryptWithTagSize(ct, key, nonce, ad, tt.tagSize, nil); err != ErrAuthentication {
+			t.Errorf("#%d: tampered tag got %v", i, err)
+		}
+	}
+}
+
+func TestCCMLimits(t *testing.T) {
+	key := make([]byte, 16)
+	msg := []byte("ccm")
+	for n := 0; n <= 16; n++ {
+		_, err := CCM.Encrypt(msg, key, make([]byte, n), nil, nil)
+		if ok := n >= 7 && n <= 13; ok != (err == nil) {
+			t.Errorf("nonce size %d: got %v", n, err)
+		} else if !ok && err != CCMNonceSizeError(n) {
+			t.Errorf("nonce size %d: got %v", n, err)
+		}
+	}
+	for n := 0; n <= 18; n++ {
+		_, err := CCM.EncryptWithTagSize(msg, key, make([]byte, 12), nil, n, nil)
+		if ok := n >= 4 && n <= 16 && n%2 == 0; ok != (err == nil) {
+			t.Errorf("tag size %d: got %v", n, err)
+		} else if !ok && err != CCMTagSizeError(n) {
+			t.Errorf("tag size %d: got %v", n, err)
+		}
+	}
+	// A 13-byte nonce leaves a 2-byte length field.
+	if err := CCM.ValidDataSize(1<<16-1, 13); err != nil {
+		t.Error(err)
+	}
+	if err := CCM.ValidDataSize(1<<16, 13); err != CCMDataSizeError(1<<16) {
+		t.Errorf("got %v", err)
+	}
+}
@@ -3,21 +3,145 @@ package aes
 import (
 	stdaes "crypto/aes"
 	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+	"io"
 
 	"github.com/colduction/aes/padding"
 )
 
+type CFBSegmentSizeError int
+
+func (i CFBSegmentSizeError) Error() string {
+	return fmt.Sprintf("aes-cfb: invalid segment size %d, sizes of 1, 8, 64 and 128 bits are allowed", int(i))
+}
+
+// ValidSegmentSize checks the segment size in bits.
+func (cfb) ValidSegmentSize(bits int) error {
+	switch bits {
+	case 1, 8, 64, 128:
+		return nil
+	}
+	return CFBSegmentSizeError(bits)
+}
+
+// cfbSegment implements CFB with a segment size of whole bytes smaller than the block size.
+type cfbSegment struct {
+	block   cipher.Block
+	next    []byte // shift register
+	out     []byte // keystream of the current segment
+	ct      []byte // ciphertext of the current segment
+	used    int
+	decrypt bool
+}
+
+func (x *cfbSegment) XORKeyStream(dst, src []byte) {
+	seg := len(x.ct)
+	for len(src) > 0 {
+		if x.used == 0 {
+			x.block.Encrypt(x.out, x.next)
+		}
+		n := min(len(src), seg-x.used)
+		if x.decrypt {
+			copy(x.ct[x.used:], src[:n])
+		}
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ x.out[x.used+i]
+		}
+		if !x.decrypt {
+			copy(x.ct[x.used:], dst[:n])
+		}
+		x.used += n
+		if x.used == seg {
+			copy(x.next, x.next[seg:])
+			copy(x.next[len(x.next)-seg:], x.ct)
+			x.used = 0
+		}
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// cfbBit implements CFB1, processing each byte from its most significant bit.
+type cfbBit struct {
+	block   cipher.Block
+	next    []byte
+	out     []byte
+	decrypt bool
+}
+
+func (x *cfbBit) XORKeyStream(dst, src []byte) {
+	for i, in := range src {
+		var o byte
+		for bit := 7; bit >= 0; bit-- {
+			x.block.Encrypt(x.out, x.next)
+			b := in >> bit & 1
+			c := b ^ x.out[0]>>7
+			o |= c << bit
+			if x.decrypt {
+				c = b
+			}
+			for j := 0; j < len(x.next)-1; j++ {
+				x.next[j] = x.next[j]<<1 | x.next[j+1]>>7
+			}
+			x.next[len(x.next)-1] = x.next[len(x.next)-1]<<1 | c
+		}
+		dst[i] = o
+	}
+}
+
+// newCFB returns a CFB stream with a segment size in bits, which is a full block or a smaller valid segment size.
+func newCFB(block cipher.Block, iv []byte, segmentSize int, decrypt bool) cipher.Stream {
+	bs := block.BlockSize()
+	if segmentSize == 8*bs {
+		if decrypt {
+			return cipher.NewCFBDecrypter(block, iv)
+		}
+		return cipher.NewCFBEncrypter(block, iv)
+	}
+	next := make([]byte, bs)
+	copy(next, iv)
+	if segmentSize == 1 {
+		return &cfbBit{block: block, next: next, out: make([]byte, bs), decrypt: decrypt}
+	}
+	return &cfbSegment{block: block, next: next, out: make([]byte, bs), ct: make([]byte, segmentSize/8), decrypt: decrypt}
+}
+
 // Encrypts input using AES in CFB mode
 func (cfb) Encrypt(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
-	lenInput := len(input)
-	if lenInput == 0 {
+	return cfbEncryptWithKey(input, key, iv, stdaes.BlockSize, 8*stdaes.BlockSize, pad)
+}
+
+// Encrypts input using AES in CFB mode with custom segment size in bits (1, 8, 64 or 128)
+func (cfb) EncryptWithSegmentSize(input, key, iv []byte, segmentSize int, pad padding.Padding) ([]byte, error) {
+	if err := CFB.ValidSegmentSize(segmentSize); err != nil {
+		return nil, err
+	}
+	return cfbEncryptWithKey(input, key, iv, stdaes.BlockSize, segmentSize, pad)
+}
+
+// Encrypts input using Rijndael in CFB mode with custom block size (16, 24 or 32) and full block segments
+func (cfb) EncryptWithBlockSize(input, key, iv []byte, blocksize int, pad padding.Padding) ([]byte, error) {
+	return cfbEncryptWithKey(input, key, iv, blocksize, 8*blocksize, pad)
+}
+
+func cfbEncryptWithKey(input, key, iv []byte, blocksize, segmentSize int, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
 		return nil, InvalidDataError(lenInput)
 	}
-	block, err := stdaes.NewCipher(key)
+	block, err := NewRijndael(key, blocksize)
 	if err != nil {
 		return nil, err
 	}
-	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+	return cfbEncrypt(block, input, iv, segmentSize, pad)
+}
+
+func cfbEncrypt(block cipher.Block, input, iv []byte, segmentSize int, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	err := IvSizeEquality(len(iv), block.BlockSize())
+	if err != nil {
 		return nil, err
 	}
 	if pad != nil {
@@ -27,25 +151,141 @@ func (cfb) Encrypt(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
 		lenInput = len(input)
 	}
 	ct := make([]byte, lenInput)
-	stream := cipher.NewCFBEncrypter(block, iv)
+	stream := newCFB(block, iv, segmentSize, false)
 	stream.XORKeyStream(ct, input)
 	return ct, nil
 }
 
-// Decrypts ciphertext using AES in CFB mode
-func (cfb) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+// Encrypts input using AES in CFB mode and appends the ciphertext to dst, without allocating
+// the output when dst has room for input and a block more,
+// the key schedule is still allocated on every call, use Cipher to avoid it
+func (cfb) EncryptTo(dst, input, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cfbEncryptTo(block, dst, input, iv, pad)
+}
+
+func cfbEncryptTo(block cipher.Block, dst, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	if err := IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	ret, err := appendPadded(dst, input, pad, block.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	cfbEncryptInPlace(block, ret[len(dst):], iv)
+	return ret, nil
+}
+
+// Decrypts ciphertext using AES in CFB mode and appends the plaintext to dst, without allocating
+// the output when dst has room for ciphertext,
+// the key schedule is still allocated on every call, use Cipher to avoid it
+func (cfb) DecryptTo(dst, ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	if lenCt := len(ciphertext); lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cfbDecryptTo(block, dst, ciphertext, iv, pad)
+}
+
+func cfbDecryptTo(block cipher.Block, dst, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
 	lenCt := len(ciphertext)
 	if lenCt == 0 {
 		return nil, InvalidCiphertextError(lenCt)
 	}
-	block, err := stdaes.NewCipher(key)
+	if err := IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	ret := append(dst, ciphertext...)
+	cfbDecryptInPlace(block, ret[len(dst):], iv)
+	return unpadAppended(ret, len(dst), pad, block.BlockSize())
+}
+
+// cfbEncryptInPlace encrypts buf in CFB mode with full block segments.
+func cfbEncryptInPlace(block cipher.Block, buf, iv []byte) {
+	bs := block.BlockSize()
+	s := scratchPool.Get().(*[64]byte)
+	keystream := s[:bs]
+	prev := iv
+	for i := 0; i < len(buf); i += bs {
+		block.Encrypt(keystream, prev)
+		n := subtle.XORBytes(buf[i:], buf[i:], keystream)
+		prev = buf[i : i+n]
+	}
+	scratchPool.Put(s)
+}
+
+// cfbDecryptInPlace decrypts buf in CFB mode with full block segments, going
+// backwards to keep the previous ciphertext segment available in place.
+func cfbDecryptInPlace(block cipher.Block, buf, iv []byte) {
+	if len(buf) == 0 {
+		return
+	}
+	bs := block.BlockSize()
+	s := scratchPool.Get().(*[64]byte)
+	keystream := s[:bs]
+	for i := (len(buf) - 1) / bs * bs; i >= 0; i -= bs {
+		prev := iv
+		if i > 0 {
+			prev = buf[i-bs : i]
+		}
+		block.Encrypt(keystream, prev)
+		subtle.XORBytes(buf[i:], buf[i:], keystream)
+	}
+	scratchPool.Put(s)
+}
+
+// Decrypts ciphertext using AES in CFB mode
+func (cfb) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	return cfbDecryptWithKey(ciphertext, key, iv, stdaes.BlockSize, 8*stdaes.BlockSize, pad)
+}
+
+// Decrypts ciphertext using AES in CFB mode with custom segment size in bits (1, 8, 64 or 128)
+func (cfb) DecryptWithSegmentSize(ciphertext, key, iv []byte, segmentSize int, pad padding.Padding) ([]byte, error) {
+	if err := CFB.ValidSegmentSize(segmentSize); err != nil {
+		return nil, err
+	}
+	return cfbDecryptWithKey(ciphertext, key, iv, stdaes.BlockSize, segmentSize, pad)
+}
+
+// Decrypts ciphertext using Rijndael in CFB mode with custom block size (16, 24 or 32) and full block segments
+func (cfb) DecryptWithBlockSize(ciphertext, key, iv []byte, blocksize int, pad padding.Padding) ([]byte, error) {
+	return cfbDecryptWithKey(ciphertext, key, iv, blocksize, 8*blocksize, pad)
+}
+
+func cfbDecryptWithKey(ciphertext, key, iv []byte, blocksize, segmentSize int, pad padding.Padding) ([]byte, error) {
+	if lenCt := len(ciphertext); lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := NewRijndael(key, blocksize)
 	if err != nil {
 		return nil, err
 	}
-	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+	return cfbDecrypt(block, ciphertext, iv, segmentSize, pad)
+}
+
+func cfbDecrypt(block cipher.Block, ciphertext, iv []byte, segmentSize int, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	err := IvSizeEquality(len(iv), block.BlockSize())
+	if err != nil {
 		return nil, err
 	}
-	mode := cipher.NewCFBDecrypter(block, iv)
+	mode := newCFB(block, iv, segmentSize, true)
 	pt := make([]byte, lenCt)
 	mode.XORKeyStream(pt, ciphertext)
 	if pad != nil {
@@ -56,3 +296,60 @@ func (cfb) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, err
 	}
 	return pt, nil
 }
+
+// Returns a writer encrypting to w using AES in CFB mode, the padding is applied on Close which does not close w
+func (cfb) NewEncryptWriter(w io.Writer, key, iv []byte, pad padding.Padding) (io.WriteCloser, error) {
+	if err := validStreamPadding(pad); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	return newEncryptWriter(w, nil, cipher.NewCFBEncrypter(block, iv), block.BlockSize(), pad), nil
+}
+
+// Returns a reader decrypting from r using AES in CFB mode, the padding is removed from the last block at EOF
+func (cfb) NewDecryptReader(r io.Reader, key, iv []byte, pad padding.Padding) (io.Reader, error) {
+	if err := validStreamPadding(pad); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	return newDecryptReader(r, nil, cipher.NewCFBDecrypter(block, iv), block.BlockSize(), pad), nil
+}
+
+// Encrypts input using AES in CFB mode under a random IV read from rand, crypto/rand.Reader when nil,
+// and returns the IV followed by the ciphertext
+func (cfb) EncryptAuto(rand io.Reader, input, key []byte, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	ret, err := appendRandom(make([]byte, 0, len(input)+2*bs), rand, bs)
+	if err != nil {
+		return nil, err
+	}
+	return cfbEncryptTo(block, ret, input, ret[:bs], pad)
+}
+
+// Decrypts ciphertext made of the IV followed by the ciphertext using AES in CFB mode, as returned by EncryptAuto
+func (cfb) DecryptAuto(ciphertext, key []byte, pad padding.Padding) ([]byte, error) {
+	iv, rest, err := splitPrefix(ciphertext, stdaes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	return CFB.DecryptTo(nil, rest, key, iv, pad)
+}
@@ -0,0 +1,78 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/colduction/aes/padding"
+)
+
+// SP 800-38A appendix F.3, CFB1 bits packed most significant first.
+var cfbTests = []struct {
+	name                   string
+	segmentSize            int
+	key, plaintext, output string
+}{
+	{"F.3.1 CFB1-AES128", 1, "2b7e151628aed2a6abf7158809cf4f3c", "6bc1", "68b3"},
+	{"F.3.3 CFB1-AES192", 1, "8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b", "6bc1", "9359"},
+	{"F.3.5 CFB1-AES256", 1, "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", "6bc1", "9029"},
+	{
+		"F.3.7 CFB8-AES128", 8, "2b7e151628aed2a6abf7158809cf4f3c",
+		"6bc1bee22e409f96e93d7e117393172aae2d", "3b79424c9c0dd436bace9e0ed4586a4f32b9",
+	},
+	{
+		"F.3.9 CFB8-AES192", 8, "8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b",
+		"6bc1bee22e409f96e93d7e117393172aae2d", "cda2521ef0a905ca44cd057cbf0d47a0678a",
+	},
+	{
+		"F.3.11 CFB8-AES256", 8, "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4",
+		"6bc1bee22e409f96e93d7e117393172aae2d", "dc1f1a8520a64db55fcc8ac554844e889700",
+	},
+	{
+		"F.3.13 CFB128-AES128", 128, "2b7e151628aed2a6abf7158809cf4f3c",
+		"6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710",
+		"3b3fd92eb72dad20333449f8e83cfb4ac8a64537a0b3a93fcde3cdad9f1ce58b26751f67a3cbb140b1808cf187a4f4dfc04b05357c5d1c0eeac4c66f9ff7f2e6",
+	},
+}
+
+func TestCFBVectors(t *testing.T) {
+	iv := fromHex(t, "000102030405060708090a0b0c0d0e0f")
+	for _, tt := range cfbTests {
+		key, pt, want := fromHex(t, tt.key), fromHex(t, tt.plaintext), fromHex(t, tt.output)
+		ct, err := CFB.EncryptWithSegmentSize(pt, key, iv, tt.segmentSize, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !bytes.Equal(ct, want) {
+			t.Errorf("%s: got %x, want %x", tt.name, ct, want)
+		}
+		got, err := CFB.DecryptWithSegmentSize(ct, key, iv, tt.segmentSize, nil)
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("%s: decrypt got %x, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestCFBSegmentSizes(t *testing.T) {
+	key := fromHex(t, cfbTests[0].key)
+	iv := fromHex(t, "000102030405060708090a0b0c0d0e0f")
+	msg := []byte("segment sizes are fun to test with odd lengths")
+	for _, s := range []int{1, 8, 64, 128} {
+		ct, err := CFB.EncryptWithSegmentSize(msg, key, iv, s, padding.PKCS7)
+		if err != nil {
+			t.Fatalf("%d bits: %v", s, err)
+		}
+		pt, err := CFB.DecryptWithSegmentSize(ct, key, iv, s, padding.PKCS7)
+		if err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%d bits: got %q, %v", s, pt, err)
+		}
+	}
+	for _, s := range []int{-8, 0, 2, 7, 16, 32, 129, 136, 256} {
+		if _, err := CFB.EncryptWithSegmentSize(msg, key, iv, s, nil); err != CFBSegmentSizeError(s) {
+			t.Errorf("encrypt %d bits: got %v", s, err)
+		}
+		if _, err := CFB.DecryptWithSegmentSize(msg, key, iv, s, nil); err != CFBSegmentSizeError(s) {
+			t.Errorf("decrypt %d bits: got %v", s, err)
+		}
+	}
+}
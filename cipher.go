@@ -0,0 +1,238 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"sync"
+
+	"github.com/colduction/aes/padding"
+)
+
+// Cipher holds an AES key schedule and GCM tables computed once, so every
+// mode can be run under the same key without re-expanding it. It is safe for
+// concurrent use by multiple goroutines.
+type Cipher struct {
+	block    cipher.Block
+	gcm      cipher.AEAD
+	gcmNonce sync.Map // nonce size -> cipher.AEAD
+	gcmTag   sync.Map // tag size -> cipher.AEAD
+}
+
+// New creates a Cipher for the given 16, 24 or 32 bytes key.
+func New(key []byte) (*Cipher, error) {
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{block: block, gcm: aed}, nil
+}
+
+// BlockSize returns the block size of the cipher.
+func (c *Cipher) BlockSize() int { return c.block.BlockSize() }
+
+// Encrypts input in CBC mode
+func (c *Cipher) EncryptCBC(input, iv []byte, pad padding.Padding) ([]byte, error) {
+	return cbcEncrypt(c.block, input, iv, pad)
+}
+
+// Decrypts ciphertext in CBC mode
+func (c *Cipher) DecryptCBC(ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	return cbcDecrypt(c.block, ciphertext, iv, pad)
+}
+
+// Encrypts input in CBC mode and appends the ciphertext to dst, without allocating when dst has room for input and a block more
+func (c *Cipher) EncryptCBCTo(dst, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	return cbcEncryptTo(c.block, dst, input, iv, pad)
+}
+
+// Decrypts ciphertext in CBC mode and appends the plaintext to dst, without allocating when dst has room for ciphertext
+func (c *Cipher) DecryptCBCTo(dst, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	return cbcDecryptTo(c.block, dst, ciphertext, iv, pad)
+}
+
+// Encrypts input in CFB mode
+func (c *Cipher) EncryptCFB(input, iv []byte, pad padding.Padding) ([]byte, error) {
+	return cfbEncrypt(c.block, input, iv, 8*c.block.BlockSize(), pad)
+}
+
+// Decrypts ciphertext in CFB mode
+func (c *Cipher) DecryptCFB(ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	return cfbDecrypt(c.block, ciphertext, iv, 8*c.block.BlockSize(), pad)
+}
+
+// Encrypts input in CFB mode and appends the ciphertext to dst, without allocating when dst has room for input and a block more
+func (c *Cipher) EncryptCFBTo(dst, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	return cfbEncryptTo(c.block, dst, input, iv, pad)
+}
+
+// Decrypts ciphertext in CFB mode and appends the plaintext to dst, without allocating when dst has room for ciphertext
+func (c *Cipher) DecryptCFBTo(dst, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	return cfbDecryptTo(c.block, dst, ciphertext, iv, pad)
+}
+
+// Encrypts input in CFB mode with custom segment size in bits (1, 8, 64 or 128)
+func (c *Cipher) EncryptCFBWithSegmentSize(input, iv []byte, segmentSize int, pad padding.Padding) ([]byte, error) {
+	if err := CFB.ValidSegmentSize(segmentSize); err != nil {
+		return nil, err
+	}
+	return cfbEncrypt(c.block, input, iv, segmentSize, pad)
+}
+
+// Decrypts ciphertext in CFB mode with custom segment size in bits (1, 8, 64 or 128)
+func (c *Cipher) DecryptCFBWithSegmentSize(ciphertext, iv []byte, segmentSize int, pad padding.Padding) ([]byte, error) {
+	if err := CFB.ValidSegmentSize(segmentSize); err != nil {
+		return nil, err
+	}
+	return cfbDecrypt(c.block, ciphertext, iv, segmentSize, pad)
+}
+
+// Encrypts input in CTR mode
+func (c *Cipher) EncryptCTR(input, iv []byte, pad padding.Padding) ([]byte, error) {
+	return ctrEncrypt(c.block, input, iv, pad)
+}
+
+// Decrypts ciphertext in CTR mode
+func (c *Cipher) DecryptCTR(ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	return ctrDecrypt(c.block, ciphertext, iv, pad)
+}
+
+// Encrypts input in CTR mode and appends the ciphertext to dst, without allocating when dst has room for input and a block more
+func (c *Cipher) EncryptCTRTo(dst, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	return ctrEncryptTo(c.block, dst, input, iv, pad)
+}
+
+// Decrypts ciphertext in CTR mode and appends the plaintext to dst, without allocating when dst has room for ciphertext
+func (c *Cipher) DecryptCTRTo(dst, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	return ctrDecryptTo(c.block, dst, ciphertext, iv, pad)
+}
+
+// Encrypts input in ECB mode
+func (c *Cipher) EncryptECB(input []byte, pad padding.Padding) ([]byte, error) {
+	return ecbEncrypt(c.block, input, pad)
+}
+
+// Decrypts ciphertext in ECB mode
+func (c *Cipher) DecryptECB(ciphertext []byte, pad padding.Padding) ([]byte, error) {
+	return ecbDecrypt(c.block, ciphertext, pad)
+}
+
+// Encrypts input in ECB mode and appends the ciphertext to dst, without allocating when dst has room for input and a block more
+func (c *Cipher) EncryptECBTo(dst, input []byte, pad padding.Padding) ([]byte, error) {
+	return ecbEncryptTo(c.block, dst, input, pad)
+}
+
+// Decrypts ciphertext in ECB mode and appends the plaintext to dst, without allocating when dst has room for ciphertext
+func (c *Cipher) DecryptECBTo(dst, ciphertext []byte, pad padding.Padding) ([]byte, error) {
+	return ecbDecryptTo(c.block, dst, ciphertext, pad)
+}
+
+// Encrypts input in OFB mode
+func (c *Cipher) EncryptOFB(input, iv []byte, pad padding.Padding) ([]byte, error) {
+	return ofbEncrypt(c.block, input, iv, pad)
+}
+
+// Decrypts ciphertext in OFB mode
+func (c *Cipher) DecryptOFB(ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	return ofbDecrypt(c.block, ciphertext, iv, pad)
+}
+
+// Encrypts input in OFB mode and appends the ciphertext to dst, without allocating when dst has room for input and a block more
+func (c *Cipher) EncryptOFBTo(dst, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	return ofbEncryptTo(c.block, dst, input, iv, pad)
+}
+
+// Decrypts ciphertext in OFB mode and appends the plaintext to dst, without allocating when dst has room for ciphertext
+func (c *Cipher) DecryptOFBTo(dst, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	return ofbDecryptTo(c.block, dst, ciphertext, iv, pad)
+}
+
+// gcmWithNonceSize returns the cached GCM instance for the nonce size, creating it on first use.
+func (c *Cipher) gcmWithNonceSize(size int) (cipher.AEAD, error) {
+	if aed, ok := c.gcmNonce.Load(size); ok {
+		return aed.(cipher.AEAD), nil
+	}
+	aed, err := cipher.NewGCMWithNonceSize(c.block, size)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := c.gcmNonce.LoadOrStore(size, aed)
+	return actual.(cipher.AEAD), nil
+}
+
+// gcmWithTagSize returns the cached GCM instance for the tag size, creating it on first use.
+func (c *Cipher) gcmWithTagSize(size int) (cipher.AEAD, error) {
+	if err := GCM.ValidTagSize(size); err != nil {
+		return nil, err
+	}
+	if aed, ok := c.gcmTag.Load(size); ok {
+		return aed.(cipher.AEAD), nil
+	}
+	aed, err := cipher.NewGCMWithTagSize(c.block, size)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := c.gcmTag.LoadOrStore(size, aed)
+	return actual.(cipher.AEAD), nil
+}
+
+// Encrypts input in GCM mode
+func (c *Cipher) EncryptGCM(input, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	if err := GCM.ValidStdNonceSize(len(nonce)); err != nil {
+		return nil, err
+	}
+	return gcmSeal(c.gcm, input, nonce, additionalData, pad, dst)
+}
+
+// Decrypts ciphertext in GCM mode
+func (c *Cipher) DecryptGCM(ciphertext, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	if err := GCM.ValidStdNonceSize(len(nonce)); err != nil {
+		return nil, err
+	}
+	return gcmOpen(c.gcm, ciphertext, nonce, additionalData, pad, dst)
+}
+
+// Encrypts input in GCM mode with custom nonce size and default tag size (16)
+func (c *Cipher) EncryptGCMWithNonceSize(input, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	aed, err := c.gcmWithNonceSize(len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	return gcmSeal(aed, input, nonce, additionalData, pad, dst)
+}
+
+// Decrypts ciphertext in GCM mode with custom nonce size and default tag size (16)
+func (c *Cipher) DecryptGCMWithNonceSize(ciphertext, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	aed, err := c.gcmWithNonceSize(len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(aed, ciphertext, nonce, additionalData, pad, dst)
+}
+
+// Encrypts input in GCM mode with custom tag size and default nonce size (12)
+func (c *Cipher) EncryptGCMWithTagSize(input, nonce, additionalData []byte, tagSize int, pad padding.Padding, dst ...byte) ([]byte, error) {
+	if err := GCM.ValidStdNonceSize(len(nonce)); err != nil {
+		return nil, err
+	}
+	aed, err := c.gcmWithTagSize(tagSize)
+	if err != nil {
+		return nil, err
+	}
+	return gcmSeal(aed, input, nonce, additionalData, pad, dst)
+}
+
+// Decrypts ciphertext in GCM mode with custom tag size and default nonce size (12)
+func (c *Cipher) DecryptGCMWithTagSize(ciphertext, nonce, additionalData []byte, tagSize int, pad padding.Padding, dst ...byte) ([]byte, error) {
+	if err := GCM.ValidStdNonceSize(len(nonce)); err != nil {
+		return nil, err
+	}
+	aed, err := c.gcmWithTagSize(tagSize)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(aed, ciphertext, nonce, additionalData, pad, dst)
+}
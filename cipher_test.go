@@ -0,0 +1,133 @@
+package aes
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/colduction/aes/padding"
+)
+
+func TestCipherMatchesModes(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	iv := bytes.Repeat([]byte{8}, 16)
+	msg := []byte("a reused key schedule gives the same output")
+	c, err := New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name      string
+		got, want func() ([]byte, error)
+	}{
+		{"CBC", func() ([]byte, error) { return c.EncryptCBC(msg, iv, padding.PKCS7) }, func() ([]byte, error) { return CBC.Encrypt(msg, key, iv, padding.PKCS7) }},
+		{"CFB", func() ([]byte, error) { return c.EncryptCFB(msg, iv, nil) }, func() ([]byte, error) { return CFB.Encrypt(msg, key, iv, nil) }},
+		{"CTR", func() ([]byte, error) { return c.EncryptCTR(msg, iv, nil) }, func() ([]byte, error) { return CTR.Encrypt(msg, key, iv, nil) }},
+		{"ECB", func() ([]byte, error) { return c.EncryptECB(msg, padding.PKCS7) }, func() ([]byte, error) { return ECB.Encrypt(msg, key, padding.PKCS7) }},
+		{"OFB", func() ([]byte, error) { return c.EncryptOFB(msg, iv, nil) }, func() ([]byte, error) { return OFB.Encrypt(msg, key, iv, nil) }},
+		{"GCM", func() ([]byte, error) { return c.EncryptGCM(msg, iv[:12], nil, nil) }, func() ([]byte, error) { return GCM.Encrypt(msg, key, iv[:12], nil, nil) }},
+	} {
+		got, err := tt.got()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		want, err := tt.want()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %x, want %x", tt.name, got, want)
+		}
+	}
+}
+
+// TestCipherConcurrentGCM fills the nonce and tag size caches from many
+// goroutines at once, run it with -race.
+func TestCipherConcurrentGCM(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	c, err := New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("concurrent use of the cached GCM instances")
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for g := 0; g < 64; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			nonce := make([]byte, 8+g%9)
+			ct, err := c.EncryptGCMWithNonceSize(msg, nonce, nil, nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			want, _ := GCM.EncryptWithNonceSize(msg, key, nonce, nil, nil)
+			if !bytes.Equal(ct, want) {
+				errs <- fmt.Errorf("nonce size %d: got %x, want %x", len(nonce), ct, want)
+				return
+			}
+			tagSize := 12 + g%5
+			ct, err = c.EncryptGCMWithTagSize(msg, make([]byte, 12), nil, tagSize, nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			pt, err := c.DecryptGCMWithTagSize(ct, make([]byte, 12), nil, tagSize, nil)
+			if err != nil || !bytes.Equal(pt, msg) {
+				errs <- fmt.Errorf("tag size %d: got %q, %v", tagSize, pt, err)
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// benchmarkCipher runs f with a Cipher built once, the keyed forms ignore it and expand the key on every call.
+func benchmarkCipher(b *testing.B, size int, f func(*Cipher, []byte, []byte) error) {
+	key := make([]byte, 16)
+	input := make([]byte, size)
+	c, _ := New(key)
+	b.SetBytes(int64(size))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := f(c, key, input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCipherReuse(b *testing.B) {
+	iv := make([]byte, 16)
+	modes := []struct {
+		name   string
+		cipher func(*Cipher, []byte, []byte) error
+		keyed  func(*Cipher, []byte, []byte) error
+	}{
+		{
+			"CBC",
+			func(c *Cipher, _, in []byte) error { _, err := c.EncryptCBC(in, iv, nil); return err },
+			func(_ *Cipher, key, in []byte) error { _, err := CBC.Encrypt(in, key, iv, nil); return err },
+		},
+		{
+			"CTR",
+			func(c *Cipher, _, in []byte) error { _, err := c.EncryptCTR(in, iv, nil); return err },
+			func(_ *Cipher, key, in []byte) error { _, err := CTR.Encrypt(in, key, iv, nil); return err },
+		},
+		{
+			"GCM",
+			func(c *Cipher, _, in []byte) error { _, err := c.EncryptGCM(in, iv[:12], nil, nil); return err },
+			func(_ *Cipher, key, in []byte) error { _, err := GCM.Encrypt(in, key, iv[:12], nil, nil); return err },
+		},
+	}
+	for _, size := range []int{64, 1024, 16384} {
+		for _, m := range modes {
+			b.Run(fmt.Sprintf("%s/%d/Cipher", m.name, size), func(b *testing.B) { benchmarkCipher(b, size, m.cipher) })
+			b.Run(fmt.Sprintf("%s/%d/Key", m.name, size), func(b *testing.B) { benchmarkCipher(b, size, m.keyed) })
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+)
+
+const (
+	cmacBlockSize  int  = 16
+	cmacMinTagSize int  = 8
+	cmacRb         byte = 0x87
+)
+
+type CMACTagSizeError int
+
+func (i CMACTagSizeError) Error() string {
+	return fmt.Sprintf("aes-cmac: incorrect tag size %d, sizes between 8 and 16 bytes are allowed", int(i))
+}
+
+// cmacDigest implements hash.Hash for CMAC over a 128-bit block cipher.
+type cmacDigest struct {
+	block   cipher.Block
+	k1, k2  [cmacBlockSize]byte
+	x       [cmacBlockSize]byte
+	buf     [cmacBlockSize]byte
+	off     int
+	tagSize int
+}
+
+// gf128Double multiplies src by x in GF(2^128) as defined by NIST SP 800-38B
+// and stores the result in dst, in constant time. dst and src may overlap.
+func gf128Double(dst, src *[cmacBlockSize]byte) {
+	msb := src[0] >> 7
+	for i := 0; i < cmacBlockSize-1; i++ {
+		dst[i] = src[i]<<1 | src[i+1]>>7
+	}
+	dst[cmacBlockSize-1] = src[cmacBlockSize-1]<<1 ^ (cmacRb & -msb)
+}
+
+// newCMAC derives the CMAC subkeys K1 and K2 from block.
+func newCMAC(block cipher.Block, tagSize int) *cmacDigest {
+	d := &cmacDigest{block: block, tagSize: tagSize}
+	var l [cmacBlockSize]byte
+	block.Encrypt(l[:], l[:])
+	gf128Double(&d.k1, &l)
+	gf128Double(&d.k2, &d.k1)
+	return d
+}
+
+func (d *cmacDigest) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		// The last block is kept in buf until Sum since it is masked with a subkey.
+		if d.off == cmacBlockSize {
+			subtle.XORBytes(d.x[:], d.x[:], d.buf[:])
+			d.block.Encrypt(d.x[:], d.x[:])
+			d.off = 0
+		}
+		c := copy(d.buf[d.off:], p)
+		d.off += c
+		p = p[c:]
+	}
+	return n, nil
+}
+
+func (d *cmacDigest) Sum(b []byte) []byte {
+	var last [cmacBlockSize]byte
+	if d.off == cmacBlockSize {
+		subtle.XORBytes(last[:], d.buf[:], d.k1[:])
+	} else {
+		copy(last[:], d.buf[:d.off])
+		last[d.off] = 0x80
+		subtle.XORBytes(last[:], last[:], d.k2[:])
+	}
+	subtle.XORBytes(last[:], last[:], d.x[:])
+	d.block.Encrypt(last[:], last[:])
+	return append(b, last[:d.tagSize]...)
+}
+
+func (d *cmacDigest) Reset() {
+	d.x = [cmacBlockSize]byte{}
+	d.off = 0
+}
+
+func (d *cmacDigest) Size() int { return d.tagSize }
+
+func (d *cmacDigest) BlockSize() int { return cmacBlockSize }
+
+func (cmac) ValidTagSize(length int) error {
+	if length < cmacMinTagSize || length > cmacBlockSize {
+		return CMACTagSizeError(length)
+	}
+	return nil
+}
+
+// Returns a streaming hash.Hash computing the AES-CMAC of the written data with a full 16 bytes tag
+func (cmac) New(key []byte) (hash.Hash, error) {
+	return CMAC.NewWithTagSize(key, cmacBlockSize)
+}
+
+// Returns a streaming hash.Hash computing the AES-CMAC of the written data truncated to tagSize bytes
+func (cmac) NewWithTagSize(key []byte, tagSize int) (hash.Hash, error) {
+	if err := CMAC.ValidTagSize(tagSize); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newCMAC(block, tagSize), nil
+}
+
+// Computes the AES-CMAC of input, an empty input is allowed
+func (cmac) Sum(input, key []byte) ([]byte, error) {
+	return CMAC.SumWithTagSize(input, key, cmacBlockSize)
+}
+
+// Computes the AES-CMAC of input truncated to tagSize bytes, an empty input is allowed
+func (cmac) SumWithTagSize(input, key []byte, tagSize int) ([]byte, error) {
+	h, err := CMAC.NewWithTagSize(key, tagSize)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(input)
+	return h.Sum(nil), nil
+}
+
+// Verifies in constant time that tag is the (possibly truncated) AES-CMAC of input
+func (cmac) Verify(input, key, tag []byte) (bool, error) {
+	expected, err := CMAC.SumWithTagSize(input, key, len(tag))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(expected, tag) == 1, nil
+}
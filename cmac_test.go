@@ -0,0 +1,90 @@
+package aes
+
+import (
+	"bytes"
+	stdaes "crypto/aes"
+	"testing"
+)
+
+const cmacTestMessage = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+
+// RFC 4493 section 4 and NIST SP 800-38B appendix D.
+var cmacTests = []struct {
+	key    string
+	length int
+	tag    string
+}{
+	{"2b7e151628aed2a6abf7158809cf4f3c", 0, "bb1d6929e95937287fa37d129b756746"},
+	{"2b7e151628aed2a6abf7158809cf4f3c", 16, "070a16b46b4d4144f79bdd9dd04a287c"},
+	{"2b7e151628aed2a6abf7158809cf4f3c", 40, "dfa66747de9ae63030ca32611497c827"},
+	{"2b7e151628aed2a6abf7158809cf4f3c", 64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	{"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", 0, "028962f61b7bf89efc6b551f4667d983"},
+	{"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", 64, "e1992190549f6ed5696a2c056c315410"},
+}
+
+func TestCMACSum(t *testing.T) {
+	msg := fromHex(t, cmacTestMessage)
+	for _, tt := range cmacTests {
+		key, want := fromHex(t, tt.key), fromHex(t, tt.tag)
+		got, err := CMAC.Sum(msg[:tt.length], key)
+		if err != nil {
+			t.Fatalf("key %s, length %d: %v", tt.key, tt.length, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("key %s, length %d: got %x, want %x", tt.key, tt.length, got, want)
+		}
+	}
+}
+
+func TestCMACStreaming(t *testing.T) {
+	msg := fromHex(t, cmacTestMessage)
+	for _, tt := range cmacTests {
+		h, err := CMAC.New(fromHex(t, tt.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < tt.length; i++ {
+			h.Write(msg[i : i+1])
+		}
+		if got := h.Sum(nil); !bytes.Equal(got, fromHex(t, tt.tag)) {
+			t.Errorf("length %d: got %x, want %s", tt.length, got, tt.tag)
+		}
+		h.Reset()
+		h.Write(msg[:tt.length])
+		if got := h.Sum(nil); !bytes.Equal(got, fromHex(t, tt.tag)) {
+			t.Errorf("length %d after Reset: got %x, want %s", tt.length, got, tt.tag)
+		}
+	}
+}
+
+func TestCMACSubkeys(t *testing.T) {
+	block, err := stdaes.NewCipher(fromHex(t, "2b7e151628aed2a6abf7158809cf4f3c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newCMAC(block, cmacBlockSize)
+	if want := fromHex(t, "fbeed618357133667c85e08f7236a8de"); !bytes.Equal(d.k1[:], want) {
+		t.Errorf("K1: got %x, want %x", d.k1, want)
+	}
+	if want := fromHex(t, "f7ddac306ae266ccf90bc11ee46d513b"); !bytes.Equal(d.k2[:], want) {
+		t.Errorf("K2: got %x, want %x", d.k2, want)
+	}
+}
+
+func TestCMACVerify(t *testing.T) {
+	key := fromHex(t, "2b7e151628aed2a6abf7158809cf4f3c")
+	msg := fromHex(t, cmacTestMessage)[:40]
+	tag := fromHex(t, "dfa66747de9ae63030ca32611497c827")
+	for _, size := range []int{8, 12, 16} {
+		if ok, err := CMAC.Verify(msg, key, tag[:size]); !ok || err != nil {
+			t.Errorf("tag size %d: got %v, %v", size, ok, err)
+		}
+	}
+	tag[0] ^= 1
+	if ok, _ := CMAC.Verify(msg, key, tag); ok {
+		t.Error("tampered tag verified")
+	}
+	if _, err := CMAC.Verify(msg, key, tag[:4]); err != CMACTagSizeError(4) {
+		t.Errorf("4 bytes tag: got %v", err)
+	}
+}
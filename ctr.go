@@ -3,22 +3,137 @@ package aes
 import (
 	stdaes "crypto/aes"
 	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
 
 	"github.com/colduction/aes/padding"
 )
 
+const (
+	ctrRFC3686NonceSize int = 4
+	ctrRFC3686IvSize    int = 8
+)
+
+type (
+	CTRCounterSizeError int
+	CTRNonceSizeError   int
+	CTROverflowError    int
+)
+
+func (i CTRCounterSizeError) Error() string {
+	return fmt.Sprintf("aes-ctr: invalid counter size %d bits, 32, 64 and 128 bits are allowed", int(i))
+}
+
+func (i CTRNonceSizeError) Error() string {
+	return fmt.Sprintf("aes-ctr: invalid nonce size %d, it must fill the counter block with the counter", int(i))
+}
+
+func (i CTROverflowError) Error() string {
+	return fmt.Sprintf("aes-ctr: the %d bits counter overflows", int(i))
+}
+
+func (ctr) ValidCounterSize(bits int) error {
+	switch bits {
+	case 32, 64, 128:
+		return nil
+	}
+	return CTRCounterSizeError(bits)
+}
+
+// Checks that processing length bytes from the counter block iv does not wrap
+// around its counter made of the last counterSize bits
+func (ctr) ValidCounter(iv []byte, counterSize, length int) error {
+	if err := CTR.ValidCounterSize(counterSize); err != nil {
+		return err
+	}
+	if err := IvSizeEquality(len(iv), stdaes.BlockSize); err != nil {
+		return err
+	}
+	if length <= 0 {
+		return nil
+	}
+	blocks := (length + stdaes.BlockSize - 1) / stdaes.BlockSize
+	last := uint64(blocks - 1)
+	var overflow bool
+	switch counterSize {
+	case 32:
+		overflow = uint64(binary.BigEndian.Uint32(iv[12:]))+last > math.MaxUint32
+	case 64:
+		_, carry := bits.Add64(binary.BigEndian.Uint64(iv[8:]), last, 0)
+		overflow = carry != 0
+	case 128:
+		_, carry := bits.Add64(binary.BigEndian.Uint64(iv[8:]), last, 0)
+		_, carry = bits.Add64(binary.BigEndian.Uint64(iv[:8]), 0, carry)
+		overflow = carry != 0
+	}
+	if overflow {
+		return CTROverflowError(counterSize)
+	}
+	return nil
+}
+
+// Builds a counter block made of nonce followed by the big-endian initial counter
+// of counterSize bits, the nonce must be 16 - counterSize/8 bytes
+func (ctr) CounterBlock(nonce []byte, counter uint64, counterSize int) ([]byte, error) {
+	if err := CTR.ValidCounterSize(counterSize); err != nil {
+		return nil, err
+	}
+	lenNonce := len(nonce)
+	if lenNonce != stdaes.BlockSize-counterSize/8 {
+		return nil, CTRNonceSizeError(lenNonce)
+	}
+	if counterSize == 32 && counter > math.MaxUint32 {
+		return nil, CTROverflowError(counterSize)
+	}
+	block := make([]byte, stdaes.BlockSize)
+	copy(block, nonce)
+	binary.BigEndian.PutUint64(block[8:], binary.BigEndian.Uint64(block[8:])|counter)
+	return block, nil
+}
+
+// Builds the RFC 3686 counter block nonce || iv || counter from the 4 bytes nonce
+// and the 8 bytes per-packet iv, the 32 bits counter starting at 1
+func (ctr) RFC3686CounterBlock(nonce, iv []byte) ([]byte, error) {
+	if lenNonce := len(nonce); lenNonce != ctrRFC3686NonceSize {
+		return nil, CTRNonceSizeError(lenNonce)
+	}
+	if lenIv := len(iv); lenIv != ctrRFC3686IvSize {
+		return nil, IvSizeError(lenIv)
+	}
+	block := make([]byte, 0, stdaes.BlockSize)
+	block = append(append(block, nonce...), iv...)
+	return binary.BigEndian.AppendUint32(block, 1), nil
+}
+
 // Encrypts input using AES in CTR mode
 func (ctr) Encrypt(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
-	lenInput := len(input)
-	if lenInput == 0 {
+	return CTR.EncryptWithBlockSize(input, key, iv, stdaes.BlockSize, pad)
+}
+
+// Encrypts input using Rijndael in CTR mode with custom block size (16, 24 or 32)
+func (ctr) EncryptWithBlockSize(input, key, iv []byte, blocksize int, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
 		return nil, InvalidDataError(lenInput)
 	}
-	block, err := stdaes.NewCipher(key)
+	block, err := NewRijndael(key, blocksize)
 	if err != nil {
 		return nil, err
 	}
+	return ctrEncrypt(block, input, iv, pad)
+}
+
+func ctrEncrypt(block cipher.Block, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
 	lenIv := len(iv)
-	if err = IvSizeEquality(lenIv, block.BlockSize()); err != nil {
+	err := IvSizeEquality(lenIv, block.BlockSize())
+	if err != nil {
 		return nil, err
 	}
 	if pad != nil {
@@ -33,18 +148,139 @@ func (ctr) Encrypt(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
 	return ct, nil
 }
 
-// Decrypts ciphertext using AES in CTR mode
-func (ctr) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+// Encrypts input using AES in CTR mode with a counter of counterSize bits (32, 64 or 128) in the last bytes of iv,
+// returning an error instead of wrapping the counter around
+func (ctr) EncryptWithCounterSize(input, key, iv []byte, counterSize int, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	if pad != nil {
+		var err error
+		if input, err = pad.Pad(input, stdaes.BlockSize); err != nil {
+			return nil, err
+		}
+	}
+	if err := CTR.ValidCounter(iv, counterSize, len(input)); err != nil {
+		return nil, err
+	}
+	return CTR.Encrypt(input, key, iv, nil)
+}
+
+// Decrypts ciphertext using AES in CTR mode with a counter of counterSize bits (32, 64 or 128) in the last bytes of iv,
+// returning an error instead of wrapping the counter around
+func (ctr) DecryptWithCounterSize(ciphertext, key, iv []byte, counterSize int, pad padding.Padding) ([]byte, error) {
 	lenCt := len(ciphertext)
 	if lenCt == 0 {
 		return nil, InvalidCiphertextError(lenCt)
 	}
+	if err := CTR.ValidCounter(iv, counterSize, lenCt); err != nil {
+		return nil, err
+	}
+	return CTR.Decrypt(ciphertext, key, iv, pad)
+}
+
+// Encrypts input using AES in CTR mode and appends the ciphertext to dst, without allocating
+// the output when dst has room for input and a block more,
+// the key schedule is still allocated on every call, use Cipher to avoid it
+func (ctr) EncryptTo(dst, input, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
 	block, err := stdaes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
+	return ctrEncryptTo(block, dst, input, iv, pad)
+}
+
+func ctrEncryptTo(block cipher.Block, dst, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	if err := IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	ret, err := appendPadded(dst, input, pad, block.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	ctrXORInPlace(block, ret[len(dst):], iv)
+	return ret, nil
+}
+
+// Decrypts ciphertext using AES in CTR mode and appends the plaintext to dst, without allocating
+// the output when dst has room for ciphertext,
+// the key schedule is still allocated on every call, use Cipher to avoid it
+func (ctr) DecryptTo(dst, ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	if lenCt := len(ciphertext); lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return ctrDecryptTo(block, dst, ciphertext, iv, pad)
+}
+
+func ctrDecryptTo(block cipher.Block, dst, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	if err := IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	ret := append(dst, ciphertext...)
+	ctrXORInPlace(block, ret[len(dst):], iv)
+	return unpadAppended(ret, len(dst), pad, block.BlockSize())
+}
+
+// ctrXORInPlace XORs buf with the CTR keystream starting at the counter block iv.
+func ctrXORInPlace(block cipher.Block, buf, iv []byte) {
+	bs := block.BlockSize()
+	s := scratchPool.Get().(*[64]byte)
+	counter, keystream := s[:bs], s[bs:2*bs]
+	copy(counter, iv)
+	for len(buf) > 0 {
+		block.Encrypt(keystream, counter)
+		buf = buf[subtle.XORBytes(buf, buf, keystream):]
+		for i := bs - 1; i >= 0; i-- {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
+		}
+	}
+	scratchPool.Put(s)
+}
+
+// Decrypts ciphertext using AES in CTR mode
+func (ctr) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	return CTR.DecryptWithBlockSize(ciphertext, key, iv, stdaes.BlockSize, pad)
+}
+
+// Decrypts ciphertext using Rijndael in CTR mode with custom block size (16, 24 or 32)
+func (ctr) DecryptWithBlockSize(ciphertext, key, iv []byte, blocksize int, pad padding.Padding) ([]byte, error) {
+	if lenCt := len(ciphertext); lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := NewRijndael(key, blocksize)
+	if err != nil {
+		return nil, err
+	}
+	return ctrDecrypt(block, ciphertext, iv, pad)
+}
+
+func ctrDecrypt(block cipher.Block, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
 	lenIv := len(iv)
-	if err = IvSizeEquality(lenIv, block.BlockSize()); err != nil {
+	err := IvSizeEquality(lenIv, block.BlockSize())
+	if err != nil {
 		return nil, err
 	}
 	mode := cipher.NewCTR(block, iv)
@@ -58,3 +294,172 @@ func (ctr) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, err
 	}
 	return pt, nil
 }
+
+// Returns a writer encrypting to w using AES in CTR mode, the padding is applied on Close which does not close w
+func (ctr) NewEncryptWriter(w io.Writer, key, iv []byte, pad padding.Padding) (io.WriteCloser, error) {
+	if err := validStreamPadding(pad); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	return newEncryptWriter(w, nil, cipher.NewCTR(block, iv), block.BlockSize(), pad), nil
+}
+
+// Returns a reader decrypting from r using AES in CTR mode, the padding is removed from the last block at EOF
+func (ctr) NewDecryptReader(r io.Reader, key, iv []byte, pad padding.Padding) (io.Reader, error) {
+	if err := validStreamPadding(pad); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	return newDecryptReader(r, nil, cipher.NewCTR(block, iv), block.BlockSize(), pad), nil
+}
+
+// ctrAddCounter returns a copy of the big-endian counter block iv incremented
+// by blocks, wrapping around like cipher.NewCTR.
+func ctrAddCounter(iv []byte, blocks uint64) []byte {
+	counter := make([]byte, len(iv))
+	copy(counter, iv)
+	ctrAdd(counter, blocks)
+	return counter
+}
+
+// ctrAdd adds blocks to the big-endian counter block in place.
+func ctrAdd(counter []byte, blocks uint64) {
+	carry := blocks
+	for i := len(counter) - 1; i >= 0 && carry > 0; i-- {
+		carry += uint64(counter[i])
+		counter[i] = byte(carry)
+		carry >>= 8
+	}
+}
+
+// ctrParallel XORs src with the keystream starting at the counter block iv into dst across workers goroutines.
+func ctrParallel(block cipher.Block, dst, src, iv []byte, workers int) {
+	bs := block.BlockSize()
+	parallelize(len(src), bs, workers, func(start, end int) {
+		cipher.NewCTR(block, ctrAddCounter(iv, uint64(start/bs))).XORKeyStream(dst[start:end], src[start:end])
+	})
+}
+
+// Encrypts input using AES in CTR mode across workers goroutines, GOMAXPROCS when workers <= 0
+func (ctr) EncryptParallel(input, key, iv []byte, workers int, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	if pad != nil {
+		if input, err = pad.Pad(input, block.BlockSize()); err != nil {
+			return nil, err
+		}
+	}
+	ct := make([]byte, len(input))
+	ctrParallel(block, ct, input, iv, workers)
+	return ct, nil
+}
+
+// Decrypts ciphertext using AES in CTR mode across workers goroutines, GOMAXPROCS when workers <= 0
+func (ctr) DecryptParallel(ciphertext, key, iv []byte, workers int, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	pt := make([]byte, lenCt)
+	ctrParallel(block, pt, ciphertext, iv, workers)
+	if pad != nil {
+		pt, err = pad.Unpad(pt, block.BlockSize())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pt, nil
+}
+
+type ctrReaderAt struct {
+	r     io.ReaderAt
+	block cipher.Block
+	iv    []byte
+}
+
+// Returns a reader decrypting the size bytes of r using AES in CTR mode at any offset,
+// the counter block of an offset is computed without processing the preceding data
+func (ctr) NewReaderAt(r io.ReaderAt, size int64, key, iv []byte) (*io.SectionReader, error) {
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(&ctrReaderAt{r: r, block: block, iv: iv}, 0, size), nil
+}
+
+func (c *ctrReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	if n == 0 {
+		return n, err
+	}
+	bs := int64(c.block.BlockSize())
+	counter := ctrAddCounter(c.iv, uint64(off/bs))
+	buf := p[:n]
+	if skip := off % bs; skip > 0 {
+		// The keystream block of a partial first block is used from the offset on.
+		var keystream [stdaes.BlockSize]byte
+		c.block.Encrypt(keystream[:], counter)
+		buf = buf[subtle.XORBytes(buf, buf, keystream[skip:]):]
+		ctrAdd(counter, 1)
+	}
+	cipher.NewCTR(c.block, counter).XORKeyStream(buf, buf)
+	return n, err
+}
+
+// Encrypts input using AES in CTR mode under a random IV read from rand, crypto/rand.Reader when nil,
+// and returns the IV followed by the ciphertext
+func (ctr) EncryptAuto(rand io.Reader, input, key []byte, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	ret, err := appendRandom(make([]byte, 0, len(input)+2*bs), rand, bs)
+	if err != nil {
+		return nil, err
+	}
+	return ctrEncryptTo(block, ret, input, ret[:bs], pad)
+}
+
+// Decrypts ciphertext made of the IV followed by the ciphertext using AES in CTR mode, as returned by EncryptAuto
+func (ctr) DecryptAuto(ciphertext, key []byte, pad padding.Padding) ([]byte, error) {
+	iv, rest, err := splitPrefix(ciphertext, stdaes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	return CTR.DecryptTo(nil, rest, key, iv, pad)
+}
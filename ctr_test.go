@@ -0,0 +1,152 @@
+package aes
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/colduction/aes/padding"
+)
+
+func TestCTRReaderAt(t *testing.T) {
+	key := bytes.Repeat([]byte{3}, 16)
+	// The counter carries into the upper bytes after the first block.
+	iv := bytes.Repeat([]byte{0xff}, 16)
+	iv[0] = 1
+	pt := make([]byte, 5000)
+	for i := range pt {
+		pt[i] = byte(i * 3)
+	}
+	ct, err := CTR.Encrypt(pt, key, iv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr, err := CTR.NewReaderAt(bytes.NewReader(ct), int64(len(ct)), key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 500; i++ {
+		off := rng.IntN(len(pt))
+		b := make([]byte, rng.IntN(300)+1)
+		n, err := sr.ReadAt(b, int64(off))
+		want := pt[off:min(off+len(b), len(pt))]
+		if !bytes.Equal(b[:n], want) {
+			t.Fatalf("offset %d, length %d: got %x, want %x", off, len(b), b[:n], want)
+		}
+		if n < len(b) && err != io.EOF {
+			t.Fatalf("offset %d, length %d: got %v", off, len(b), err)
+		}
+	}
+	if _, err = sr.Seek(777, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	rest, err := io.ReadAll(sr)
+	if err != nil || !bytes.Equal(rest, pt[777:]) {
+		t.Errorf("read after seek: %v", err)
+	}
+}
+
+// RFC 3686 section 6, the AES-128 test vectors.
+var rfc3686Tests = []struct {
+	key, nonce, iv, plaintext, ciphertext string
+}{
+	{
+		"ae6852f8121067cc4bf7a5765577f39e", "00000030", "0000000000000000",
+		"53696e676c6520626c6f636b206d7367",
+		"e4095d4fb7a7b3792d6175a3261311b8",
+	},
+	{
+		"7e24067817fae0d743d6ce1f32539163", "006cb6db", "c0543b59da48d90b",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"5104a106168a72d9790d41ee8edad388eb2e1efc46da57c8fce630df9141be28",
+	},
+	{
+		"7691be035e5020a8ac6e618529f9a0dc", "00e0017b", "27777f3f4a1786f0",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20212223",
+		"c1cf48a89f2ffdd9cf4652e9efdb72d74540a42bde6d7836d59a5ceaaef3105325b2072f",
+	},
+}
+
+func TestCTRRFC3686(t *testing.T) {
+	for i, tt := range rfc3686Tests {
+		block, err := CTR.RFC3686CounterBlock(fromHex(t, tt.nonce), fromHex(t, tt.iv))
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, pt, want := fromHex(t, tt.key), fromHex(t, tt.plaintext), fromHex(t, tt.ciphertext)
+		ct, err := CTR.EncryptWithCounterSize(pt, key, block, 32, nil)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !bytes.Equal(ct, want) {
+			t.Errorf("#%d: got %x, want %x", i, ct, want)
+		}
+		got, err := CTR.DecryptWithCounterSize(ct, key, block, 32, nil)
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("#%d: decrypt got %x, %v", i, got, err)
+		}
+	}
+	if _, err := CTR.RFC3686CounterBlock(make([]byte, 3), make([]byte, 8)); err != CTRNonceSizeError(3) {
+		t.Errorf("short nonce got %v", err)
+	}
+	if _, err := CTR.RFC3686CounterBlock(make([]byte, 4), make([]byte, 12)); err != IvSizeError(12) {
+		t.Errorf("long iv got %v", err)
+	}
+}
+
+func TestCTRCounterOverflow(t *testing.T) {
+	key := make([]byte, 16)
+	for _, counterSize := range []int{32, 64, 128} {
+		// The counter starts 3 blocks before its maximum, so 4 blocks fit.
+		iv := make([]byte, 16)
+		for i := 16 - counterSize/8; i < 16; i++ {
+			iv[i] = 0xff
+		}
+		iv[15] = 0xfc
+		if err := CTR.ValidCounter(iv, counterSize, 4*16); err != nil {
+			t.Errorf("%d bits, 4 blocks: %v", counterSize, err)
+		}
+		if err := CTR.ValidCounter(iv, counterSize, 4*16+1); err != CTROverflowError(counterSize) {
+			t.Errorf("%d bits, 4 blocks and a byte: got %v", counterSize, err)
+		}
+		if _, err := CTR.EncryptWithCounterSize(make([]byte, 4*16), key, iv, counterSize, nil); err != nil {
+			t.Errorf("%d bits: encrypt %v", counterSize, err)
+		}
+		// The padding adds a fifth block.
+		if _, err := CTR.EncryptWithCounterSize(make([]byte, 4*16), key, iv, counterSize, padding.PKCS7); err != CTROverflowError(counterSize) {
+			t.Errorf("%d bits: padded encrypt got %v", counterSize, err)
+		}
+		if _, err := CTR.DecryptWithCounterSize(make([]byte, 5*16), key, iv, counterSize, nil); err != CTROverflowError(counterSize) {
+			t.Errorf("%d bits: decrypt got %v", counterSize, err)
+		}
+	}
+	if err := CTR.ValidCounter(make([]byte, 16), 16, 16); err != CTRCounterSizeError(16) {
+		t.Errorf("16 bits got %v", err)
+	}
+}
+
+func TestCTRCounterBlock(t *testing.T) {
+	for _, tt := range []struct {
+		counterSize int
+		counter     uint64
+		want        string
+	}{
+		{32, 1, "0102030405060708090a0b0c00000001"},
+		{64, 0x0102, "01020304050607080000000000000102"},
+		{128, 0xffffffffffffffff, "0000000000000000ffffffffffffffff"},
+	} {
+		nonce := fromHex(t, "0102030405060708090a0b0c")[:16-tt.counterSize/8]
+		block, err := CTR.CounterBlock(nonce, tt.counter, tt.counterSize)
+		if err != nil || !bytes.Equal(block, fromHex(t, tt.want)) {
+			t.Errorf("%d bits: got %x, %v", tt.counterSize, block, err)
+		}
+	}
+	if _, err := CTR.CounterBlock(make([]byte, 12), 1<<32, 32); err != CTROverflowError(32) {
+		t.Errorf("33 bits counter got %v", err)
+	}
+	if _, err := CTR.CounterBlock(make([]byte, 8), 1, 32); err != CTRNonceSizeError(8) {
+		t.Errorf("short nonce got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/colduction/aes/padding"
+)
+
+const (
+	eaxBlockSize  int = 16
+	eaxMinTagSize int = 4
+)
+
+type EAXTagSizeError int
+
+func (i EAXTagSizeError) Error() string {
+	return fmt.Sprintf("aes-eax: incorrect tag size %d, sizes between 4 and 16 bytes are allowed", int(i))
+}
+
+func (eax) ValidTagSize(length int) error {
+	if length < eaxMinTagSize || length > eaxBlockSize {
+		return EAXTagSizeError(length)
+	}
+	return nil
+}
+
+// eaxOMAC computes the tweaked OMAC^t of data, which is the CMAC of [t]_16 || data.
+func eaxOMAC(out *[eaxBlockSize]byte, mac *cmacDigest, t byte, data []byte) {
+	var b [eaxBlockSize]byte
+	b[eaxBlockSize-1] = t
+	mac.Reset()
+	mac.Write(b[:])
+	mac.Write(data)
+	mac.Sum(out[:0])
+}
+
+// eaxTag computes the tag from the OMAC of the nonce, the associated data and the ciphertext.
+func eaxTag(tag *[eaxBlockSize]byte, mac *cmacDigest, n *[eaxBlockSize]byte, ciphertext, additionalData []byte) {
+	var h, c [eaxBlockSize]byte
+	eaxOMAC(&h, mac, 1, additionalData)
+	eaxOMAC(&c, mac, 2, ciphertext)
+	subtle.XORBytes(tag[:], n[:], h[:])
+	subtle.XORBytes(tag[:], tag[:], c[:])
+}
+
+// Encrypts input using AES in EAX mode with default tag size (16), the nonce may be of any size
+func (eax) Encrypt(input, key, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	return EAX.EncryptWithTagSize(input, key, nonce, additionalData, eaxBlockSize, pad, dst...)
+}
+
+// Encrypts input using AES in EAX mode with custom tag size, the nonce may be of any size
+func (eax) EncryptWithTagSize(input, key, nonce, additionalData []byte, tagSize int, pad padding.Padding, dst ...byte) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	err := EAX.ValidTagSize(tagSize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if pad != nil {
+		if input, err = pad.Pad(input, block.BlockSize()); err != nil {
+			return nil, err
+		}
+		lenInput = len(input)
+	}
+	mac := newCMAC(block, eaxBlockSize)
+	var n, tag [eaxBlockSize]byte
+	eaxOMAC(&n, mac, 0, nonce)
+	ret, out := sliceForAppend(dst, lenInput+tagSize)
+	cipher.NewCTR(block, n[:]).XORKeyStream(out, input)
+	eaxTag(&tag, mac, &n, out[:lenInput], additionalData)
+	copy(out[lenInput:], tag[:tagSize])
+	return ret, nil
+}
+
+// Decrypts ciphertext using AES in EAX mode with default tag size (16)
+func (eax) Decrypt(ciphertext, key, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	return EAX.DecryptWithTagSize(ciphertext, key, nonce, additionalData, eaxBlockSize, pad, dst...)
+}
+
+// Decrypts ciphertext using AES in EAX mode with custom tag size
+func (eax) DecryptWithTagSize(ciphertext, key, nonce, additionalData []byte, tagSize int, pad padding.Padding, dst ...byte) ([]byte, error) {
+	err := EAX.ValidTagSize(tagSize)
+	if err != nil {
+		return nil, err
+	}
+	lenCt := len(ciphertext)
+	if lenCt < tagSize {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	mac := newCMAC(block, eaxBlockSize)
+	var n, tag [eaxBlockSize]byte
+	eaxOMAC(&n, mac, 0, nonce)
+	eaxTag(&tag, mac, &n, ciphertext[:lenCt-tagSize], additionalData)
+	if subtle.ConstantTimeCompare(tag[:tagSize], ciphertext[lenCt-tagSize:]) != 1 {
+		return nil, ErrAuthentication
+	}
+	ret, pt := sliceForAppend(dst, lenCt-tagSize)
+	cipher.NewCTR(block, n[:]).XORKeyStream(pt, ciphertext[:lenCt-tagSize])
+	if pad != nil {
+		if pt, err = pad.Unpad(pt, block.BlockSize()); err != nil {
+			return nil, err
+		}
+		ret = append(ret[:len(dst)], pt...)
+	}
+	return ret, nil
+}
@@ -0,0 +1,46 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Test vectors from the EAX paper (Bellare, Rogaway, Wagner), appendix.
+var eaxTests = []struct {
+	msg, key, nonce, header, ciphertext string
+}{
+	{"", "233952dee4d5ed5f9b9c6d6ff80ff478", "62ec67f9c3a4a407fcb2a8c49031a8b3", "6bfb914fd07eae6b", "e037830e8389f27b025a2d6527e79d01"},
+	{"f7fb", "91945d3f4dcbee0bf45ef52255f095a4", "becaf043b0a23d843194ba972c66debd", "fa3bfd4806eb53fa", "19dd5c4c9331049d0bdab0277408f67967e5"},
+	{"1a47cb4933", "01f74ad64077f2e704c0f60ada3dd523", "70c3db4f0d26368400a10ed05d2bff5e", "234a3463c1264ac6", "d851d5bae03a59f238a23e39199dc9266626c40f80"},
+}
+
+func TestEAXVectors(t *testing.T) {
+	for i, tt := range eaxTests {
+		msg, key, nonce, header := fromHex(t, tt.msg), fromHex(t, tt.key), fromHex(t, tt.nonce), fromHex(t, tt.header)
+		want := fromHex(t, tt.ciphertext)
+		// Encrypt rejects empty input, the empty message is only checked on decryption.
+		if len(msg) > 0 {
+			ct, err := EAX.Encrypt(msg, key, nonce, header, nil)
+			if err != nil {
+				t.Fatalf("#%d: %v", i, err)
+			}
+			if !bytes.Equal(ct, want) {
+				t.Errorf("#%d: got %x, want %x", i, ct, want)
+			}
+		}
+		pt, err := EAX.Decrypt(want, key, nonce, header, nil)
+		if err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("#%d: decrypt got %x, %v", i, pt, err)
+		}
+		if _, err := EAX.Decrypt(want, key, nonce, nil, nil); err != ErrAuthentication {
+			t.Errorf("#%d: wrong header got %v", i, err)
+		}
+		// A truncated tag is a prefix of the full one.
+		if len(msg) > 0 {
+			ct, err := EAX.EncryptWithTagSize(msg, key, nonce, header, 8, nil)
+			if err != nil || !bytes.Equal(ct, want[:len(msg)+8]) {
+				t.Errorf("#%d: 8-byte tag got %x, %v", i, ct, err)
+			}
+		}
+	}
+}
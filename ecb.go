@@ -2,21 +2,36 @@ package aes
 
 import (
 	stdaes "crypto/aes"
+	"crypto/cipher"
+	"io"
 
 	"github.com/colduction/aes/padding"
 )
 
 // Encrypts input using AES in ECB mode
 func (ecb) Encrypt(input, key []byte, pad padding.Padding) ([]byte, error) {
-	lenInput := len(input)
-	if lenInput == 0 {
+	return ECB.EncryptWithBlockSize(input, key, stdaes.BlockSize, pad)
+}
+
+// Encrypts input using Rijndael in ECB mode with custom block size (16, 24 or 32)
+func (ecb) EncryptWithBlockSize(input, key []byte, blocksize int, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
 		return nil, InvalidDataError(lenInput)
 	}
-	block, err := stdaes.NewCipher(key)
+	block, err := NewRijndael(key, blocksize)
 	if err != nil {
 		return nil, err
 	}
+	return ecbEncrypt(block, input, pad)
+}
+
+func ecbEncrypt(block cipher.Block, input []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
 	if pad != nil {
+		var err error
 		if input, err = pad.Pad(input, block.BlockSize()); err != nil {
 			return nil, err
 		}
@@ -32,8 +47,152 @@ func (ecb) Encrypt(input, key []byte, pad padding.Padding) ([]byte, error) {
 	return ct, nil
 }
 
+// Encrypts input using AES in ECB mode and appends the ciphertext to dst, without allocating
+// the output when dst has room for input and a block more,
+// the key schedule is still allocated on every call, use Cipher to avoid it
+func (ecb) EncryptTo(dst, input, key []byte, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return ecbEncryptTo(block, dst, input, pad)
+}
+
+func ecbEncryptTo(block cipher.Block, dst, input []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	ret, err := appendPadded(dst, input, pad, block.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	ct := ret[len(dst):]
+	if len(ct)%block.BlockSize() != 0 {
+		return nil, InvalidDataError(len(ct))
+	}
+	ecbBlockMode{block: block}.CryptBlocks(ct, ct)
+	return ret, nil
+}
+
 // Decrypts ciphertext using AES in ECB mode
 func (ecb) Decrypt(ciphertext, key []byte, pad padding.Padding) ([]byte, error) {
+	return ECB.DecryptWithBlockSize(ciphertext, key, stdaes.BlockSize, pad)
+}
+
+// Decrypts ciphertext using Rijndael in ECB mode with custom block size (16, 24 or 32)
+func (ecb) DecryptWithBlockSize(ciphertext, key []byte, blocksize int, pad padding.Padding) ([]byte, error) {
+	if lenCt := len(ciphertext); lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := NewRijndael(key, blocksize)
+	if err != nil {
+		return nil, err
+	}
+	return ecbDecrypt(block, ciphertext, pad)
+}
+
+func ecbDecrypt(block cipher.Block, ciphertext []byte, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	err := ValidCiphertext(lenCt, block.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	pt := make([]byte, lenCt)
+	for bs, be := 0, block.BlockSize(); bs < lenCt; bs, be = bs+block.BlockSize(), be+block.BlockSize() {
+		block.Decrypt(pt[bs:be], ciphertext[bs:be])
+	}
+	if pad != nil {
+		pt, err = pad.Unpad(pt, block.BlockSize())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pt, nil
+}
+
+// Decrypts ciphertext using AES in ECB mode and appends the plaintext to dst, without allocating
+// the output when dst has room for ciphertext,
+// the key schedule is still allocated on every call, use Cipher to avoid it
+func (ecb) DecryptTo(dst, ciphertext, key []byte, pad padding.Padding) ([]byte, error) {
+	if lenCt := len(ciphertext); lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return ecbDecryptTo(block, dst, ciphertext, pad)
+}
+
+func ecbDecryptTo(block cipher.Block, dst, ciphertext []byte, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	if err := ValidCiphertext(lenCt, block.BlockSize()); err != nil {
+		return nil, err
+	}
+	ret := append(dst, ciphertext...)
+	pt := ret[len(dst):]
+	ecbBlockMode{block: block, decrypt: true}.CryptBlocks(pt, pt)
+	return unpadAppended(ret, len(dst), pad, block.BlockSize())
+}
+
+// ecbBlockMode implements cipher.BlockMode for ECB.
+type ecbBlockMode struct {
+	block   cipher.Block
+	decrypt bool
+}
+
+func (x ecbBlockMode) BlockSize() int { return x.block.BlockSize() }
+
+func (x ecbBlockMode) CryptBlocks(dst, src []byte) {
+	bs := x.block.BlockSize()
+	for i := 0; i < len(src); i += bs {
+		if x.decrypt {
+			x.block.Decrypt(dst[i:i+bs], src[i:i+bs])
+		} else {
+			x.block.Encrypt(dst[i:i+bs], src[i:i+bs])
+		}
+	}
+}
+
+// Encrypts input using AES in ECB mode across workers goroutines, GOMAXPROCS when workers <= 0
+func (ecb) EncryptParallel(input, key []byte, workers int, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if pad != nil {
+		if input, err = pad.Pad(input, block.BlockSize()); err != nil {
+			return nil, err
+		}
+		lenInput = len(input)
+	}
+	if lenInput%block.BlockSize() != 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	ct := make([]byte, lenInput)
+	mode := ecbBlockMode{block: block}
+	parallelize(lenInput, block.BlockSize(), workers, func(start, end int) {
+		mode.CryptBlocks(ct[start:end], input[start:end])
+	})
+	return ct, nil
+}
+
+// Decrypts ciphertext using AES in ECB mode across workers goroutines, GOMAXPROCS when workers <= 0
+func (ecb) DecryptParallel(ciphertext, key []byte, workers int, pad padding.Padding) ([]byte, error) {
 	lenCt := len(ciphertext)
 	if lenCt == 0 {
 		return nil, InvalidCiphertextError(lenCt)
@@ -46,9 +205,10 @@ func (ecb) Decrypt(ciphertext, key []byte, pad padding.Padding) ([]byte, error)
 		return nil, err
 	}
 	pt := make([]byte, lenCt)
-	for bs, be := 0, block.BlockSize(); bs < lenCt; bs, be = bs+block.BlockSize(), be+block.BlockSize() {
-		block.Decrypt(pt[bs:be], ciphertext[bs:be])
-	}
+	mode := ecbBlockMode{block: block, decrypt: true}
+	parallelize(lenCt, block.BlockSize(), workers, func(start, end int) {
+		mode.CryptBlocks(pt[start:end], ciphertext[start:end])
+	})
 	if pad != nil {
 		pt, err = pad.Unpad(pt, block.BlockSize())
 		if err != nil {
@@ -57,3 +217,27 @@ func (ecb) Decrypt(ciphertext, key []byte, pad padding.Padding) ([]byte, error)
 	}
 	return pt, nil
 }
+
+// Returns a writer encrypting to w using AES in ECB mode, the padding is applied on Close which does not close w
+func (ecb) NewEncryptWriter(w io.Writer, key []byte, pad padding.Padding) (io.WriteCloser, error) {
+	if err := validStreamPadding(pad); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newEncryptWriter(w, ecbBlockMode{block: block}, nil, block.BlockSize(), pad), nil
+}
+
+// Returns a reader decrypting from r using AES in ECB mode, the padding is removed from the last block at EOF
+func (ecb) NewDecryptReader(r io.Reader, key []byte, pad padding.Padding) (io.Reader, error) {
+	if err := validStreamPadding(pad); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newDecryptReader(r, ecbBlockMode{block: block, decrypt: true}, nil, block.BlockSize(), pad), nil
+}
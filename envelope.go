@@ -0,0 +1,265 @@
+package aes
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EnvelopeVersion is the version of the envelope format written by Seal.
+const EnvelopeVersion byte = 1
+
+const envelopeFlagAdditionalData byte = 1 << 0
+
+// envelopeMACLabel derives the HMAC-SHA256 key of the modes without authentication from the key given to Seal.
+const envelopeMACLabel = "aes envelope hmac-sha256"
+
+var (
+	ErrEnvelopeAdditionalData = errors.New("aes: envelope additional data mismatch")
+	ErrEnvelopeFormat         = errors.New("aes: malformed envelope")
+)
+
+type (
+	EnvelopeAlgorithmError string
+	EnvelopeVersionError   int
+)
+
+func (a EnvelopeAlgorithmError) Error() string {
+	return fmt.Sprintf("aes: envelope algorithm %q is not allowed", string(a))
+}
+
+func (i EnvelopeVersionError) Error() string {
+	return fmt.Sprintf("aes: unsupported envelope version %d", int(i))
+}
+
+// Envelope is a self-describing ciphertext carrying what is needed to decrypt
+// it besides the key and the additional data.
+//
+// Its binary form is the version byte, a flags byte, then the algorithm, key ID,
+// IV and tag each prefixed with its uvarint length, followed by the ciphertext.
+// Its text form is the unpadded base64url encoding of the binary form.
+//
+// The header, which is the binary form up to the IV, is authenticated along
+// with the additional data. The AEAD modes take the header followed by the
+// additional data as their own, and their tag is split off the ciphertext. The
+// other modes encrypt with the key then authenticate with HMAC-SHA256, under a
+// key derived from it, the header, the length-prefixed additional data and the
+// ciphertext.
+type Envelope struct {
+	Version byte
+	// Algorithm is the canonical Lookup name, holding the mode, key size and padding String value.
+	Algorithm string
+	// KeyID identifies the key to the application, it is not interpreted.
+	KeyID string
+	IV    []byte
+	// AdditionalData reports whether additional data was authenticated and must be given to Open.
+	AdditionalData bool
+	// Tag is the authentication tag of the AEAD mode, or the HMAC-SHA256 of the other modes.
+	Tag        []byte
+	Ciphertext []byte
+}
+
+// Seal encrypts input with the algorithm resolved by Lookup under key and a
+// fresh random IV or nonce, returning it in an Envelope authenticated as
+// described there.
+func Seal(algorithm, keyID string, key, input, additionalData []byte) (*Envelope, error) {
+	m, err := Lookup(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if m.KeySize() == 0 {
+		// The algorithm ID records the key size, taken from the key when the name has none.
+		sized := strings.Replace(m.Name(), "aes-", fmt.Sprintf("aes-%d-", 8*len(key)), 1)
+		if m, err = Lookup(sized); err != nil {
+			return nil, KeySizeError(len(key))
+		}
+	}
+	iv, err := GenerateRandomBytes(m.IvSize())
+	if err != nil {
+		return nil, err
+	}
+	e := &Envelope{Version: EnvelopeVersion, Algorithm: m.Name(), KeyID: keyID, IV: iv, AdditionalData: len(additionalData) > 0}
+	a, ok := m.(AEAD)
+	if !ok {
+		if e.Ciphertext, err = m.Encrypt(input, key, iv); err != nil {
+			return nil, err
+		}
+		e.Tag = e.mac(key, additionalData)
+		return e, nil
+	}
+	ct, err := a.Seal(input, key, iv, append(e.appendHeader(nil), additionalData...))
+	if err != nil {
+		return nil, err
+	}
+	split := len(ct) - a.Overhead()
+	e.Ciphertext, e.Tag = ct[:split:split], ct[split:]
+	return e, nil
+}
+
+// Open authenticates the header, additionalData and ciphertext, then decrypts
+// the envelope with the mode named by its algorithm. That algorithm must match
+// one of algorithms, Lookup names whose key size is optional, so that an
+// envelope cannot choose the mode run with key; none is allowed when empty.
+func (e *Envelope) Open(key, additionalData []byte, algorithms []string) ([]byte, error) {
+	if e.Version != EnvelopeVersion {
+		return nil, EnvelopeVersionError(e.Version)
+	}
+	if e.AdditionalData != (len(additionalData) > 0) {
+		return nil, ErrEnvelopeAdditionalData
+	}
+	m, err := Lookup(e.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if m.Name() != e.Algorithm || !allowedAlgorithm(m, algorithms) {
+		return nil, EnvelopeAlgorithmError(e.Algorithm)
+	}
+	a, ok := m.(AEAD)
+	if !ok {
+		if len(e.Tag) != sha256.Size {
+			return nil, ErrEnvelopeFormat
+		}
+		if !hmac.Equal(e.Tag, e.mac(key, additionalData)) {
+			return nil, ErrAuthentication
+		}
+		return m.Decrypt(e.Ciphertext, key, e.IV)
+	}
+	if len(e.Tag) != a.Overhead() {
+		return nil, ErrEnvelopeFormat
+	}
+	ct := make([]byte, 0, len(e.Ciphertext)+len(e.Tag))
+	return a.Open(append(append(ct, e.Ciphertext...), e.Tag...), key, e.IV, append(e.appendHeader(nil), additionalData...))
+}
+
+// Open parses an envelope in its binary or text form and decrypts it with one
+// of algorithms, as (*Envelope).Open does.
+func Open(envelope, key, additionalData []byte, algorithms []string) ([]byte, error) {
+	var e Envelope
+	var err error
+	if len(envelope) > 0 && envelope[0] == EnvelopeVersion {
+		err = e.UnmarshalBinary(envelope)
+	} else {
+		err = e.UnmarshalText(envelope)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e.Open(key, additionalData, algorithms)
+}
+
+// allowedAlgorithm reports whether m has the mode and padding of one of
+// algorithms, and its key size unless that algorithm has none.
+func allowedAlgorithm(m Mode, algorithms []string) bool {
+	got := m.(interface{ registered() registeredMode }).registered()
+	for _, name := range algorithms {
+		allowed, err := Lookup(name)
+		if err != nil {
+			continue
+		}
+		want := allowed.(interface{ registered() registeredMode }).registered()
+		if want.keySize == 0 {
+			want.keySize = got.keySize
+		}
+		if want.Name() == got.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// mac returns the HMAC-SHA256 of the header, the length-prefixed additional
+// data and the ciphertext, under a key derived from key.
+func (e *Envelope) mac(key, additionalData []byte) []byte {
+	kdf := hmac.New(sha256.New, key)
+	kdf.Write([]byte(envelopeMACLabel))
+	h := hmac.New(sha256.New, kdf.Sum(nil))
+	h.Write(e.appendHeader(nil))
+	h.Write(binary.AppendUvarint(nil, uint64(len(additionalData))))
+	h.Write(additionalData)
+	h.Write(e.Ciphertext)
+	return h.Sum(nil)
+}
+
+// appendHeader appends the binary form of the version, flags, algorithm, key ID and IV to b.
+func (e *Envelope) appendHeader(b []byte) []byte {
+	var flags byte
+	if e.AdditionalData {
+		flags |= envelopeFlagAdditionalData
+	}
+	b = append(b, e.Version, flags)
+	for _, field := range [][]byte{[]byte(e.Algorithm), []byte(e.KeyID), e.IV} {
+		b = appendField(b, field)
+	}
+	return b
+}
+
+func appendField(b, field []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(field)))
+	return append(b, field...)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 2+4*binary.MaxVarintLen64+len(e.Algorithm)+len(e.KeyID)+len(e.IV)+len(e.Tag)+len(e.Ciphertext))
+	b = appendField(e.appendHeader(b), e.Tag)
+	return append(b, e.Ciphertext...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, the fields alias data.
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return ErrEnvelopeFormat
+	}
+	if data[0] != EnvelopeVersion {
+		return EnvelopeVersionError(data[0])
+	}
+	if data[1]&^envelopeFlagAdditionalData != 0 {
+		return ErrEnvelopeFormat
+	}
+	version, flags := data[0], data[1]
+	data = data[2:]
+	var fields [4][]byte
+	for i := range fields {
+		n, size := binary.Uvarint(data)
+		if size <= 0 || n > uint64(len(data)-size) {
+			return ErrEnvelopeFormat
+		}
+		fields[i] = data[size : size+int(n) : size+int(n)]
+		data = data[size+int(n):]
+	}
+	*e = Envelope{
+		Version:        version,
+		Algorithm:      string(fields[0]),
+		KeyID:          string(fields[1]),
+		IV:             fields[2],
+		AdditionalData: flags&envelopeFlagAdditionalData != 0,
+		Tag:            fields[3],
+		Ciphertext:     data,
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler with the unpadded base64url encoding of the binary form.
+func (e *Envelope) MarshalText() ([]byte, error) {
+	b, err := e.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	text := make([]byte, base64.RawURLEncoding.EncodedLen(len(b)))
+	base64.RawURLEncoding.Encode(text, b)
+	return text, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *Envelope) UnmarshalText(text []byte) error {
+	b := make([]byte, base64.RawURLEncoding.DecodedLen(len(text)))
+	n, err := base64.RawURLEncoding.Decode(b, text)
+	if err != nil {
+		return ErrEnvelopeFormat
+	}
+	return e.UnmarshalBinary(b[:n])
+}
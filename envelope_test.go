@@ -0,0 +1,303 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/colduction/aes/padding"
+)
+
+var envelopeAlgorithms = []string{
+	"aes-128-gcm",
+	"aes-256-gcm",
+	"AES/GCM/NoPadding",
+	"aes-128-ccm",
+	"aes-192-eax",
+	"aes-gcm-siv",
+	"aes-256-ocb",
+	"aes-128-cbc",
+	"AES/CBC/PKCS5Padding",
+	"aes-256-ecb/x923",
+	"aes-ctr",
+	"aes-192-cfb8",
+	"aes-ofb/iso7816",
+	"aes-256-ige",
+	"aes-pcbc/bit",
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{4}, 32)
+	msg := []byte("sealed in an envelope")
+	for _, alg := range envelopeAlgorithms {
+		for _, ad := range [][]byte{nil, []byte("additional data")} {
+			m, err := Lookup(alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			k := key
+			if m.KeySize() != 0 {
+				k = key[:m.KeySize()]
+			}
+			e, err := Seal(alg, "key-1", k, msg, ad)
+			if err != nil {
+				t.Fatalf("%s: %v", alg, err)
+			}
+			if e.Version != EnvelopeVersion || e.KeyID != "key-1" || e.AdditionalData != (ad != nil) || len(e.IV) != m.IvSize() {
+				t.Errorf("%s: got %+v", alg, e)
+			}
+			bin, err := e.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, err := e.MarshalText()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, data := range [][]byte{bin, text} {
+				var got Envelope
+				if bytes.Equal(data, bin) {
+					err = got.UnmarshalBinary(data)
+				} else {
+					err = got.UnmarshalText(data)
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", alg, err)
+				}
+				if got.Version != e.Version || got.Algorithm != e.Algorithm || got.KeyID != e.KeyID || !bytes.Equal(got.IV, e.IV) ||
+					got.AdditionalData != e.AdditionalData || !bytes.Equal(got.Tag, e.Tag) || !bytes.Equal(got.Ciphertext, e.Ciphertext) {
+					t.Errorf("%s: got %+v, want %+v", alg, got, *e)
+				}
+				pt, err := Open(data, k, ad, []string{alg})
+				if err != nil || !bytes.Equal(pt, msg) {
+					t.Errorf("%s: %s: got %x, %v, want %x", alg, data, pt, err, msg)
+				}
+			}
+		}
+	}
+}
+
+func TestEnvelopeAlgorithmName(t *testing.T) {
+	key := bytes.Repeat([]byte{4}, 24)
+	for _, tt := range []struct{ alg, want string }{
+		{"aes-192-gcm", "aes-192-gcm/NoPadding"},
+		{"AES/GCM/NoPadding", "aes-192-gcm/NoPadding"},
+		{"aes-eax", "aes-192-eax/NoPadding"},
+		{"aes-cbc", "aes-192-cbc/PKCS7Padding"},
+		{"AES/ECB/PKCS5Padding", "aes-192-ecb/PKCS5Padding"},
+		{"aes-192-ctr/x923", "aes-192-ctr/X923Padding"},
+	} {
+		e, err := Seal(tt.alg, "", key, []byte("msg"), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.alg, err)
+		}
+		if e.Algorithm != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.alg, e.Algorithm, tt.want)
+		}
+	}
+	if _, err := Seal("aes-gcm", "", key[:20], []byte("msg"), nil); err != KeySizeError(20) {
+		t.Errorf("got %v, want %v", err, KeySizeError(20))
+	}
+	if _, err := Seal("aes-gcm-siv", "", key, []byte("msg"), nil); err != KeySizeError(24) {
+		t.Errorf("got %v, want %v", err, KeySizeError(24))
+	}
+}
+
+// TestEnvelopeExistingCiphertext checks that the modes without authentication
+// encrypt with the key itself, so that their ciphertext is the one of the mode.
+func TestEnvelopeExistingCiphertext(t *testing.T) {
+	key := bytes.Repeat([]byte{4}, 16)
+	msg := []byte("stored before envelopes existed")
+	e, err := Seal("aes-128-cbc/PKCS7Padding", "", key, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := CBC.Encrypt(msg, key, e.IV, padding.PKCS7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(e.Ciphertext, want) {
+		t.Errorf("got %x, want %x", e.Ciphertext, want)
+	}
+	if len(e.Tag) != 32 {
+		t.Errorf("got a %d-byte tag, want the 32 bytes of HMAC-SHA256", len(e.Tag))
+	}
+}
+
+func TestEnvelopeAllowedAlgorithms(t *testing.T) {
+	key := bytes.Repeat([]byte{4}, 16)
+	gcm, err := Seal("aes-128-gcm", "", key, []byte("msg"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cbc, err := Seal("aes-128-cbc/x923", "", key, []byte("msg"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		e       *Envelope
+		allowed []string
+		ok      bool
+	}{
+		{gcm, []string{"aes-128-gcm"}, true},
+		{gcm, []string{"AES/GCM/NoPadding"}, true},
+		{gcm, []string{"aes-cbc", "aes-gcm"}, true},
+		{gcm, []string{"unknown", "aes-128-gcm/NoPadding"}, true},
+		{gcm, nil, false},
+		{gcm, []string{}, false},
+		{gcm, []string{"aes-256-gcm"}, false},
+		{gcm, []string{"aes-128-ccm", "aes-128-ocb", "aes-128-eax", "aes-gcm-siv"}, false},
+		{gcm, []string{"aes-128-gcm/pkcs7"}, false},
+		{cbc, []string{"aes-128-cbc/X923Padding"}, true},
+		{cbc, []string{"AES/CBC/ansix923"}, true},
+		{cbc, []string{"aes-128-cbc"}, false},
+		{cbc, []string{"aes-128-cbc/pkcs7", "aes-128-gcm"}, false},
+	} {
+		pt, err := tt.e.Open(key, nil, tt.allowed)
+		if tt.ok && (err != nil || string(pt) != "msg") {
+			t.Errorf("%s allowing %q: got %q, %v", tt.e.Algorithm, tt.allowed, pt, err)
+		}
+		if !tt.ok && err != EnvelopeAlgorithmError(tt.e.Algorithm) {
+			t.Errorf("%s allowing %q: got %v, want %v", tt.e.Algorithm, tt.allowed, err, EnvelopeAlgorithmError(tt.e.Algorithm))
+		}
+	}
+}
+
+// TestEnvelopeRelabel relabels a GCM envelope to every other AEAD mode, which
+// must be rejected before the key is used when not allowed, and fail to
+// authenticate otherwise.
+func TestEnvelopeRelabel(t *testing.T) {
+	key := bytes.Repeat([]byte{4}, 16)
+	e, err := Seal("aes-128-gcm", "", key, []byte("relabelled envelope"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, alg := range []string{"aes-128-ccm/NoPadding", "aes-128-eax/NoPadding", "aes-128-gcm-siv/NoPadding", "aes-128-ocb/NoPadding", "aes-128-ctr/NoPadding"} {
+		relabelled := *e
+		relabelled.Algorithm = alg
+		if _, err := relabelled.Open(key, nil, []string{"aes-128-gcm"}); err != EnvelopeAlgorithmError(alg) {
+			t.Errorf("%s: got %v, want %v", alg, err, EnvelopeAlgorithmError(alg))
+		}
+		if pt, err := relabelled.Open(key, nil, []string{"aes-128-gcm", alg}); err == nil {
+			t.Errorf("%s: got %x", alg, pt)
+		}
+	}
+	// A non-canonical spelling of the same algorithm is rejected too.
+	relabelled := *e
+	relabelled.Algorithm = "AES-128-GCM/NoPadding"
+	if _, err := relabelled.Open(key, nil, []string{"aes-128-gcm"}); err != EnvelopeAlgorithmError(relabelled.Algorithm) {
+		t.Errorf("got %v, want %v", err, EnvelopeAlgorithmError(relabelled.Algorithm))
+	}
+}
+
+func TestEnvelopeAdditionalData(t *testing.T) {
+	key := bytes.Repeat([]byte{4}, 16)
+	ad := []byte("additional data")
+	for _, alg := range []string{"aes-128-gcm", "aes-128-cbc"} {
+		allowed := []string{alg}
+		with, err := Seal(alg, "", key, []byte("msg"), ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		without, err := Seal(alg, "", key, []byte("msg"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := with.Open(key, nil, allowed); err != ErrEnvelopeAdditionalData {
+			t.Errorf("%s: got %v, want %v", alg, err, ErrEnvelopeAdditionalData)
+		}
+		if _, err := without.Open(key, ad, allowed); err != ErrEnvelopeAdditionalData {
+			t.Errorf("%s: got %v, want %v", alg, err, ErrEnvelopeAdditionalData)
+		}
+		if _, err := with.Open(key, []byte("additional datA"), allowed); err == nil {
+			t.Errorf("%s: opened with the wrong additional data", alg)
+		}
+	}
+}
+
+// TestEnvelopeTamper alters each field of a sealed envelope, every one of them
+// being authenticated so that Open must fail.
+func TestEnvelopeTamper(t *testing.T) {
+	key := bytes.Repeat([]byte{4}, 16)
+	ad := []byte("additional data")
+	for _, alg := range []string{"aes-128-gcm", "AES/GCM/NoPadding", "aes-128-ccm", "aes-eax", "aes-gcm-siv", "aes-128-ocb", "aes-128-cbc", "aes-ecb", "aes-128-ctr"} {
+		e, err := Seal(alg, "key-1", key, []byte("sealed in an envelope"), ad)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		allowed := []string{alg}
+		for _, tt := range []struct {
+			field  string
+			tamper func(e *Envelope)
+			ad     []byte
+			err    error
+		}{
+			{"Version", func(e *Envelope) { e.Version++ }, ad, EnvelopeVersionError(EnvelopeVersion + 1)},
+			{"AdditionalData", func(e *Envelope) { e.AdditionalData = false }, nil, nil},
+			{"AdditionalData", func(e *Envelope) { e.AdditionalData = false }, ad, ErrEnvelopeAdditionalData},
+			{"Algorithm", func(e *Envelope) { e.Algorithm = "AES-" + e.Algorithm[len("aes-"):] }, ad, nil},
+			{"KeyID", func(e *Envelope) { e.KeyID = "key-2" }, ad, nil},
+			{"KeyID", func(e *Envelope) { e.KeyID = "" }, ad, nil},
+			{"IV", func(e *Envelope) { e.IV = append(e.IV, 0) }, ad, nil},
+			{"Tag", func(e *Envelope) { e.Tag[len(e.Tag)-1] ^= 1 }, ad, nil},
+			{"Tag", func(e *Envelope) { e.Tag = e.Tag[:len(e.Tag)-1] }, ad, ErrEnvelopeFormat},
+			{"Ciphertext", func(e *Envelope) { e.Ciphertext[0] ^= 1 }, ad, nil},
+			{"Ciphertext", func(e *Envelope) { e.Ciphertext = e.Ciphertext[1:] }, ad, nil},
+		} {
+			tampered := *e
+			tampered.IV, tampered.Tag, tampered.Ciphertext = bytes.Clone(e.IV), bytes.Clone(e.Tag), bytes.Clone(e.Ciphertext)
+			tt.tamper(&tampered)
+			pt, err := tampered.Open(key, tt.ad, allowed)
+			if err == nil || tt.err != nil && err != tt.err {
+				t.Errorf("%s: %s: got %x, %v, want error %v", alg, tt.field, pt, err, tt.err)
+			}
+		}
+		if len(e.IV) > 0 {
+			tampered := *e
+			tampered.IV = bytes.Clone(e.IV)
+			tampered.IV[0] ^= 1
+			if pt, err := tampered.Open(key, ad, allowed); err == nil {
+				t.Errorf("%s: IV: got %x", alg, pt)
+			}
+		}
+		if pt, err := e.Open(key, ad, allowed); err != nil {
+			t.Errorf("%s: got %x, %v after tampering with copies", alg, pt, err)
+		}
+	}
+}
+
+// TestEnvelopeBinaryTamper flips every bit and truncates at every length of the
+// binary form, none of which must open.
+func TestEnvelopeBinaryTamper(t *testing.T) {
+	key := bytes.Repeat([]byte{4}, 16)
+	for _, alg := range []string{"aes-128-gcm", "aes-128-cbc"} {
+		e, err := Seal(alg, "key-1", key, []byte("msg"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bin, err := e.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		allowed := []string{"aes-gcm", "aes-cbc", "aes-ctr", "aes-ccm", "aes-ocb"}
+		for i := range bin {
+			for bit := 0; bit < 8; bit++ {
+				tampered := bytes.Clone(bin)
+				tampered[i] ^= 1 << bit
+				if pt, err := Open(tampered, key, nil, allowed); err == nil {
+					t.Errorf("%s: byte %d bit %d: got %x", alg, i, bit, pt)
+				}
+			}
+			if pt, err := Open(bin[:i], key, nil, allowed); err == nil {
+				t.Errorf("%s: truncated to %d bytes: got %x", alg, i, pt)
+			}
+		}
+		var got Envelope
+		if err := got.UnmarshalBinary(append([]byte{EnvelopeVersion, 2}, bin[2:]...)); err != ErrEnvelopeFormat {
+			t.Errorf("%s: unknown flag: got %v, want %v", alg, err, ErrEnvelopeFormat)
+		}
+	}
+	var got Envelope
+	if err := got.UnmarshalText([]byte("not base64!")); err != ErrEnvelopeFormat {
+		t.Errorf("text: got %v, want %v", err, ErrEnvelopeFormat)
+	}
+}
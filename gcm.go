@@ -4,6 +4,8 @@ import (
 	stdaes "crypto/aes"
 	"crypto/cipher"
 	"fmt"
+	"io"
+	"slices"
 	"strconv"
 
 	"github.com/colduction/aes/padding"
@@ -68,19 +70,11 @@ func (gcm) Encrypt(input, key, nonce, additionalData []byte, pad padding.Padding
 	if err != nil {
 		return nil, err
 	}
-	if err = GCM.ValidDataSize(lenInput, block.BlockSize()); err != nil {
-		return nil, err
-	}
-	if pad != nil {
-		if input, err = pad.Pad(input, block.BlockSize()); err != nil {
-			return nil, err
-		}
-	}
 	aed, err := cipher.NewGCM(block)
 	if err != nil {
 		return nil, err
 	}
-	return aed.Seal(dst, nonce, input, additionalData), nil
+	return gcmSeal(aed, input, nonce, additionalData, pad, dst)
 }
 
 // Encrypts input using AES in GCM mode with custom nonce size and default tag size (16)
@@ -93,19 +87,11 @@ func (gcm) EncryptWithNonceSize(input, key, nonce, additionalData []byte, pad pa
 	if err != nil {
 		return nil, err
 	}
-	if err = GCM.ValidDataSize(lenInput, block.BlockSize()); err != nil {
-		return nil, err
-	}
-	if pad != nil {
-		if input, err = pad.Pad(input, block.BlockSize()); err != nil {
-			return nil, err
-		}
-	}
 	aed, err := cipher.NewGCMWithNonceSize(block, len(nonce))
 	if err != nil {
 		return nil, err
 	}
-	return aed.Seal(dst, nonce, input, additionalData), nil
+	return gcmSeal(aed, input, nonce, additionalData, pad, dst)
 }
 
 // Encrypts input using AES in GCM mode with custom tag size and default nonce size (12)
@@ -125,18 +111,30 @@ func (gcm) EncryptWithTagSize(input, key, nonce, additionalData []byte, tagSize
 	if err != nil {
 		return nil, err
 	}
-	if err = GCM.ValidDataSize(lenInput, block.BlockSize()); err != nil {
+	aed, err := cipher.NewGCMWithTagSize(block, tagSize)
+	if err != nil {
 		return nil, err
 	}
-	if pad != nil {
-		if input, err = pad.Pad(input, block.BlockSize()); err != nil {
-			return nil, err
-		}
+	return gcmSeal(aed, input, nonce, additionalData, pad, dst)
+}
+
+func gcmSeal(aed cipher.AEAD, input, nonce, additionalData []byte, pad padding.Padding, dst []byte) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
 	}
-	aed, err := cipher.NewGCMWithTagSize(block, tagSize)
+	err := GCM.ValidDataSize(lenInput, gcmBlockSize)
 	if err != nil {
 		return nil, err
 	}
+	if pad != nil {
+		// The padded input is sealed in place, in the spare capacity of dst when possible.
+		ret, err := appendPadded(slices.Grow(dst, lenInput+gcmBlockSize+aed.Overhead()), input, pad, gcmBlockSize)
+		if err != nil {
+			return nil, err
+		}
+		return aed.Seal(ret[:len(dst)], nonce, ret[len(dst):], additionalData), nil
+	}
 	return aed.Seal(dst, nonce, input, additionalData), nil
 }
 
@@ -154,24 +152,11 @@ func (gcm) Decrypt(ciphertext, key, nonce, additionalData []byte, pad padding.Pa
 	if err != nil {
 		return nil, err
 	}
-	if err = GCM.ValidDataSize(lenCt, block.BlockSize()); err != nil {
-		return nil, err
-	}
 	mode, err := cipher.NewGCM(block)
 	if err != nil {
 		return nil, err
 	}
-	pt, err := mode.Open(dst, nonce, ciphertext, additionalData)
-	if err != nil {
-		return nil, err
-	}
-	if pad != nil {
-		pt, err = pad.Unpad(pt, block.BlockSize())
-		if err != nil {
-			return nil, err
-		}
-	}
-	return pt, nil
+	return gcmOpen(mode, ciphertext, nonce, additionalData, pad, dst)
 }
 
 // Decrypts ciphertext using AES in GCM mode with custom nonce size and default tag size (16)
@@ -184,24 +169,11 @@ func (gcm) DecryptWithNonceSize(ciphertext, key, nonce, additionalData []byte, p
 	if err != nil {
 		return nil, err
 	}
-	if err = GCM.ValidDataSize(lenCt, block.BlockSize()); err != nil {
-		return nil, err
-	}
 	mode, err := cipher.NewGCMWithNonceSize(block, len(nonce))
 	if err != nil {
 		return nil, err
 	}
-	pt, err := mode.Open(dst, nonce, ciphertext, additionalData)
-	if err != nil {
-		return nil, err
-	}
-	if pad != nil {
-		pt, err = pad.Unpad(pt, block.BlockSize())
-		if err != nil {
-			return nil, err
-		}
-	}
-	return pt, nil
+	return gcmOpen(mode, ciphertext, nonce, additionalData, pad, dst)
 }
 
 // Decrypts ciphertext using AES in GCM mode with custom tag size and default nonce size (12)
@@ -221,10 +193,19 @@ func (gcm) DecryptWithTagSize(ciphertext, key, nonce, additionalData []byte, tag
 	if err != nil {
 		return nil, err
 	}
-	if err = GCM.ValidDataSize(lenCt, block.BlockSize()); err != nil {
+	mode, err := cipher.NewGCMWithTagSize(block, tagSize)
+	if err != nil {
 		return nil, err
 	}
-	mode, err := cipher.NewGCMWithTagSize(block, tagSize)
+	return gcmOpen(mode, ciphertext, nonce, additionalData, pad, dst)
+}
+
+func gcmOpen(mode cipher.AEAD, ciphertext, nonce, additionalData []byte, pad padding.Padding, dst []byte) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	err := GCM.ValidDataSize(lenCt, gcmBlockSize)
 	if err != nil {
 		return nil, err
 	}
@@ -232,11 +213,27 @@ func (gcm) DecryptWithTagSize(ciphertext, key, nonce, additionalData []byte, tag
 	if err != nil {
 		return nil, err
 	}
-	if pad != nil {
-		pt, err = pad.Unpad(pt, block.BlockSize())
-		if err != nil {
-			return nil, err
-		}
+	return unpadAppended(pt, len(dst), pad, gcmBlockSize)
+}
+
+// Encrypts input using AES in GCM mode under a random standard nonce read from rand, crypto/rand.Reader when nil,
+// and appends the nonce followed by the ciphertext to dst
+func (gcm) EncryptAuto(rand io.Reader, input, key, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	ret, err := appendRandom(dst, rand, gcmStdNonceSize)
+	if err != nil {
+		return nil, err
+	}
+	return GCM.Encrypt(input, key, ret[len(dst):], additionalData, pad, ret...)
+}
+
+// Decrypts ciphertext made of the standard nonce followed by the ciphertext using AES in GCM mode, as returned by EncryptAuto
+func (gcm) DecryptAuto(ciphertext, key, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	nonce, rest, err := splitPrefix(ciphertext, gcmStdNonceSize)
+	if err != nil {
+		return nil, err
 	}
-	return pt, nil
+	return GCM.Decrypt(rest, key, nonce, additionalData, pad, dst...)
 }
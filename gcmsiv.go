@@ -0,0 +1,180 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/colduction/aes/padding"
+)
+
+const (
+	gcmsivBlockSize   int    = 16
+	gcmsivNonceSize   int    = 12
+	gcmsivTagSize     int    = 16
+	gcmsivMaxDataSize uint64 = 1 << 36
+)
+
+type (
+	GCMSIVDataSizeError  int
+	GCMSIVNonceSizeError int
+)
+
+func (i GCMSIVDataSizeError) Error() string {
+	return fmt.Sprintf("aes-gcm-siv: invalid data size %d", int(i))
+}
+
+func (i GCMSIVNonceSizeError) Error() string {
+	return fmt.Sprintf("aes-gcm-siv: invalid nonce size %d, it must equal 12 bytes", int(i))
+}
+
+func (gcmsiv) ValidKeySize(length int) error {
+	switch length {
+	case 16, 32:
+		return nil
+	}
+	return KeySizeError(length)
+}
+
+func (gcmsiv) ValidNonceSize(length int) error {
+	if length != gcmsivNonceSize {
+		return GCMSIVNonceSizeError(length)
+	}
+	return nil
+}
+
+func (gcmsiv) ValidDataSize(length int) error {
+	if uint64(length) > gcmsivMaxDataSize {
+		return GCMSIVDataSizeError(length)
+	}
+	return nil
+}
+
+// gcmsivDeriveKeys derives the per-nonce message-authentication key and
+// message-encryption block cipher from the key-generating key.
+func gcmsivDeriveKeys(key, nonce []byte) (authKey []byte, encBlock cipher.Block, err error) {
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	var in, out [gcmsivBlockSize]byte
+	copy(in[4:], nonce)
+	derived := make([]byte, 0, gcmsivBlockSize+len(key))
+	for i := uint32(0); len(derived) < cap(derived); i++ {
+		binary.LittleEndian.PutUint32(in[:4], i)
+		block.Encrypt(out[:], in[:])
+		derived = append(derived, out[:8]...)
+	}
+	if encBlock, err = stdaes.NewCipher(derived[gcmsivBlockSize:]); err != nil {
+		return nil, nil, err
+	}
+	return derived[:gcmsivBlockSize], encBlock, nil
+}
+
+// gcmsivTag computes the tag over the plaintext and additional data.
+func gcmsivTag(tag *[gcmsivTagSize]byte, authKey []byte, encBlock cipher.Block, nonce, plaintext, additionalData []byte) {
+	var lengths [gcmsivBlockSize]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*8)
+	p := newPolyval(authKey)
+	p.update(additionalData)
+	p.update(plaintext)
+	p.update(lengths[:])
+	p.sum(tag[:])
+	subtle.XORBytes(tag[:gcmsivNonceSize], tag[:gcmsivNonceSize], nonce)
+	tag[15] &= 0x7f
+	encBlock.Encrypt(tag[:], tag[:])
+}
+
+// gcmsivCTR XORs src with the keystream whose initial counter block is the tag
+// with its most significant bit set, incrementing a 32-bit little-endian counter.
+func gcmsivCTR(encBlock cipher.Block, tag *[gcmsivTagSize]byte, dst, src []byte) {
+	var counter, keystream [gcmsivBlockSize]byte
+	copy(counter[:], tag[:])
+	counter[15] |= 0x80
+	for len(src) > 0 {
+		encBlock.Encrypt(keystream[:], counter[:])
+		binary.LittleEndian.PutUint32(counter[:4], binary.LittleEndian.Uint32(counter[:4])+1)
+		n := subtle.XORBytes(dst, src, keystream[:])
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// Encrypts input using AES in GCM-SIV mode (RFC 8452), the 16 bytes tag is appended to the ciphertext
+func (gcmsiv) Encrypt(input, key, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	err := GCMSIV.ValidKeySize(len(key))
+	if err != nil {
+		return nil, err
+	}
+	if err = GCMSIV.ValidNonceSize(len(nonce)); err != nil {
+		return nil, err
+	}
+	if pad != nil {
+		if input, err = pad.Pad(input, gcmsivBlockSize); err != nil {
+			return nil, err
+		}
+	}
+	if err = GCMSIV.ValidDataSize(len(input)); err != nil {
+		return nil, err
+	}
+	if err = GCMSIV.ValidDataSize(len(additionalData)); err != nil {
+		return nil, err
+	}
+	authKey, encBlock, err := gcmsivDeriveKeys(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+	var tag [gcmsivTagSize]byte
+	gcmsivTag(&tag, authKey, encBlock, nonce, input, additionalData)
+	ret, out := sliceForAppend(dst, len(input)+gcmsivTagSize)
+	gcmsivCTR(encBlock, &tag, out, input)
+	copy(out[len(input):], tag[:])
+	return ret, nil
+}
+
+// Decrypts ciphertext using AES in GCM-SIV mode (RFC 8452)
+func (gcmsiv) Decrypt(ciphertext, key, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt < gcmsivTagSize {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	err := GCMSIV.ValidKeySize(len(key))
+	if err != nil {
+		return nil, err
+	}
+	if err = GCMSIV.ValidNonceSize(len(nonce)); err != nil {
+		return nil, err
+	}
+	if err = GCMSIV.ValidDataSize(lenCt - gcmsivTagSize); err != nil {
+		return nil, err
+	}
+	if err = GCMSIV.ValidDataSize(len(additionalData)); err != nil {
+		return nil, err
+	}
+	authKey, encBlock, err := gcmsivDeriveKeys(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+	var tag, expected [gcmsivTagSize]byte
+	copy(tag[:], ciphertext[lenCt-gcmsivTagSize:])
+	ret, pt := sliceForAppend(dst, lenCt-gcmsivTagSize)
+	gcmsivCTR(encBlock, &tag, pt, ciphertext[:lenCt-gcmsivTagSize])
+	gcmsivTag(&expected, authKey, encBlock, nonce, pt, additionalData)
+	if subtle.ConstantTimeCompare(expected[:], tag[:]) != 1 {
+		clear(pt)
+		return nil, ErrAuthentication
+	}
+	if pad != nil {
+		if pt, err = pad.Unpad(pt, gcmsivBlockSize); err != nil {
+			return nil, err
+		}
+		ret = append(ret[:len(dst)], pt...)
+	}
+	return ret, nil
+}
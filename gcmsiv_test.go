@@ -0,0 +1,57 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+// RFC 8452 appendix C.1 and C.2.
+var gcmsivTests = []struct {
+	key, nonce, ad, plaintext, ciphertext string
+}{
+	{"01000000000000000000000000000000", "030000000000000000000000", "", "", "dc20e2d83f25705bb49e439eca56de25"},
+	{"01000000000000000000000000000000", "030000000000000000000000", "", "0100000000000000", "b5d839330ac7b786578782fff6013b815b287c22493a364c"},
+	{"01000000000000000000000000000000", "030000000000000000000000", "", "010000000000000000000000", "7323ea61d05932260047d942a4978db357391a0bc4fdec8b0d106639"},
+	{"01000000000000000000000000000000", "030000000000000000000000", "01", "0200000000000000", "1e6daba35669f4273b0a1a2560969cdf790d99759abd1508"},
+	{"0100000000000000000000000000000000000000000000000000000000000000", "030000000000000000000000", "", "", "07f5f4169bbf55a8400cd47ea6fd400f"},
+	{"0100000000000000000000000000000000000000000000000000000000000000", "030000000000000000000000", "", "0100000000000000", "c2ef328e5c71c83b843122130f7364b761e0b97427e3df28"},
+}
+
+func TestGCMSIVVectors(t *testing.T) {
+	for _, tt := range gcmsivTests {
+		key, nonce, ad := fromHex(t, tt.key), fromHex(t, tt.nonce), fromHex(t, tt.ad)
+		pt, ct := fromHex(t, tt.plaintext), fromHex(t, tt.ciphertext)
+		if len(pt) > 0 {
+			got, err := GCMSIV.Encrypt(pt, key, nonce, ad, nil)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.plaintext, err)
+			}
+			if !bytes.Equal(got, ct) {
+				t.Errorf("%s: got %x, want %x", tt.plaintext, got, ct)
+			}
+		}
+		got, err := GCMSIV.Decrypt(ct, key, nonce, ad, nil)
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("%s: decrypt got %x, %v", tt.ciphertext, got, err)
+		}
+		ct[len(ct)-1] ^= 1
+		if _, err := GCMSIV.Decrypt(ct, key, nonce, ad, nil); err != ErrAuthentication {
+			t.Errorf("%s: tampered tag got %v", tt.ciphertext, err)
+		}
+	}
+}
+
+func TestGCMSIVErrors(t *testing.T) {
+	key := fromHex(t, "01000000000000000000000000000000")
+	for _, n := range []int{0, 8, 11, 13, 16} {
+		if _, err := GCMSIV.Encrypt([]byte("x"), key, make([]byte, n), nil, nil); err != GCMSIVNonceSizeError(n) {
+			t.Errorf("nonce size %d: got %v", n, err)
+		}
+		if _, err := GCMSIV.Decrypt(make([]byte, 17), key, make([]byte, n), nil, nil); err != GCMSIVNonceSizeError(n) {
+			t.Errorf("decrypt nonce size %d: got %v", n, err)
+		}
+	}
+	if _, err := GCMSIV.Encrypt([]byte("x"), make([]byte, 24), make([]byte, 12), nil, nil); err == nil {
+		t.Error("24 bytes key accepted")
+	}
+}
@@ -0,0 +1,357 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+const (
+	gcmStreamPrefixSize     int = 7
+	gcmStreamHeaderSize     int = 4 + gcmStreamPrefixSize
+	gcmStreamMinSegmentSize int = gcmBlockSize
+	gcmStreamMaxSegmentSize int = 1 << 30
+)
+
+type GCMStreamSegmentSizeError int
+
+func (i GCMStreamSegmentSizeError) Error() string {
+	return fmt.Sprintf("aes-gcm: invalid stream segment size %d, sizes between %d and %d bytes are allowed", int(i), gcmStreamMinSegmentSize, gcmStreamMaxSegmentSize)
+}
+
+func (gcm) ValidStreamSegmentSize(length int) error {
+	if length < gcmStreamMinSegmentSize || length > gcmStreamMaxSegmentSize {
+		return GCMStreamSegmentSizeError(length)
+	}
+	return nil
+}
+
+// gcmStream seals and opens the segments of a stream in the STREAM
+// construction. The header is the big-endian segment size followed by a
+// random nonce prefix, and it is authenticated with every segment along with
+// the additional data. The nonce of a segment is the prefix followed by the
+// big-endian segment index and a flag set on the last segment, so reordered,
+// dropped or truncated segments fail to authenticate.
+type gcmStream struct {
+	aead        cipher.AEAD
+	header      []byte
+	ad          []byte
+	segmentSize int
+}
+
+func newGCMStream(key, header, additionalData []byte) (*gcmStream, error) {
+	segmentSize := int(binary.BigEndian.Uint32(header))
+	if err := GCM.ValidStreamSegmentSize(segmentSize); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	ad := make([]byte, 0, len(header)+len(additionalData))
+	ad = append(append(ad, header...), additionalData...)
+	return &gcmStream{aead: aead, header: ad[:len(header)], ad: ad, segmentSize: segmentSize}, nil
+}
+
+func (s *gcmStream) nonce(index uint64, last bool) []byte {
+	var nonce [gcmStdNonceSize]byte
+	copy(nonce[:], s.header[4:])
+	binary.BigEndian.PutUint32(nonce[gcmStreamPrefixSize:], uint32(index))
+	if last {
+		nonce[gcmStdNonceSize-1] = 1
+	}
+	return nonce[:]
+}
+
+func (s *gcmStream) seal(dst, plaintext []byte, index uint64, last bool) []byte {
+	return s.aead.Seal(dst, s.nonce(index, last), plaintext, s.ad)
+}
+
+func (s *gcmStream) open(dst, ciphertext []byte, index uint64, last bool) ([]byte, error) {
+	if index > math.MaxUint32 {
+		return nil, ErrAuthentication
+	}
+	pt, err := s.aead.Open(dst, s.nonce(index, last), ciphertext, s.ad)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	return pt, nil
+}
+
+type gcmStreamWriter struct {
+	w      io.Writer
+	s      *gcmStream
+	buf    []byte
+	out    []byte
+	index  uint64
+	n      int64
+	header bool
+	err    error
+}
+
+// Returns a writer encrypting to w using AES-GCM in segments of segmentSize
+// bytes of plaintext. The last segment is sealed on Close, which does not close w.
+func (gcm) NewStreamWriter(w io.Writer, key, additionalData []byte, segmentSize int) (io.WriteCloser, error) {
+	if err := GCM.ValidStreamSegmentSize(segmentSize); err != nil {
+		return nil, err
+	}
+	header := make([]byte, gcmStreamHeaderSize)
+	binary.BigEndian.PutUint32(header, uint32(segmentSize))
+	prefix, err := GenerateRandomBytes(gcmStreamPrefixSize)
+	if err != nil {
+		return nil, err
+	}
+	copy(header[4:], prefix)
+	s, err := newGCMStream(key, header, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	return &gcmStreamWriter{w: w, s: s, buf: make([]byte, 0, segmentSize)}, nil
+}
+
+func (sw *gcmStreamWriter) writeSegment(last bool) error {
+	if !sw.header {
+		if _, err := sw.w.Write(sw.s.header); err != nil {
+			return err
+		}
+		sw.header = true
+	}
+	if sw.index > math.MaxUint32 {
+		return GCMDataSizeError(sw.n)
+	}
+	sw.out = sw.s.seal(sw.out[:0], sw.buf, sw.index, last)
+	if _, err := sw.w.Write(sw.out); err != nil {
+		return err
+	}
+	sw.buf = sw.buf[:0]
+	sw.index++
+	return nil
+}
+
+func (sw *gcmStreamWriter) Write(p []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	n := len(p)
+	for len(p) > 0 {
+		// A full segment is only sealed once more data follows, since the last one is flagged.
+		if len(sw.buf) == sw.s.segmentSize {
+			if sw.err = sw.writeSegment(false); sw.err != nil {
+				return n - len(p), sw.err
+			}
+		}
+		c := min(sw.s.segmentSize-len(sw.buf), len(p))
+		sw.buf = append(sw.buf, p[:c]...)
+		sw.n += int64(c)
+		p = p[c:]
+	}
+	return n, nil
+}
+
+// Close seals the last segment, it does not close the underlying writer.
+// Closing again returns the error of the first Close, if any.
+func (sw *gcmStreamWriter) Close() error {
+	if sw.err != nil {
+		if sw.err == io.ErrClosedPipe {
+			return nil
+		}
+		return sw.err
+	}
+	if sw.err = sw.writeSegment(true); sw.err != nil {
+		return sw.err
+	}
+	sw.err = io.ErrClosedPipe
+	return nil
+}
+
+type gcmStreamReader struct {
+	r     io.Reader
+	key   []byte
+	ad    []byte
+	s     *gcmStream
+	in    []byte
+	out   []byte
+	index uint64
+	err   error
+}
+
+// Returns a reader decrypting and authenticating from r a stream written by
+// NewStreamWriter. Each segment is only returned once it is authenticated.
+func (gcm) NewStreamReader(r io.Reader, key, additionalData []byte) (io.Reader, error) {
+	if err := ValidKeySize(len(key)); err != nil {
+		return nil, err
+	}
+	return &gcmStreamReader{r: r, key: key, ad: additionalData}, nil
+}
+
+func (sr *gcmStreamReader) readHeader() error {
+	header := make([]byte, gcmStreamHeaderSize)
+	n, err := io.ReadFull(sr.r, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return InvalidCiphertextError(n)
+	}
+	if err != nil {
+		return err
+	}
+	if sr.s, err = newGCMStream(sr.key, header, sr.ad); err != nil {
+		return err
+	}
+	// One extra byte is read ahead to know whether a full segment is the last one.
+	sr.in = make([]byte, 0, sr.s.segmentSize+sr.s.aead.Overhead()+1)
+	return nil
+}
+
+func (sr *gcmStreamReader) fill() error {
+	if sr.s == nil {
+		if err := sr.readHeader(); err != nil {
+			return err
+		}
+	}
+	n, err := io.ReadFull(sr.r, sr.in[len(sr.in):cap(sr.in)])
+	sr.in = sr.in[:len(sr.in)+n]
+	switch err {
+	case nil:
+		full := len(sr.in) - 1
+		if sr.out, err = sr.s.open(sr.out[:0], sr.in[:full], sr.index, false); err != nil {
+			return err
+		}
+		sr.in = append(sr.in[:0], sr.in[full])
+		sr.index++
+		return nil
+	case io.EOF, io.ErrUnexpectedEOF:
+		if sr.out, err = sr.s.open(sr.out[:0], sr.in, sr.index, true); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	return err
+}
+
+func (sr *gcmStreamReader) Read(p []byte) (int, error) {
+	for len(sr.out) == 0 {
+		if sr.err != nil {
+			return 0, sr.err
+		}
+		sr.err = sr.fill()
+	}
+	n := copy(p, sr.out)
+	sr.out = sr.out[n:]
+	return n, nil
+}
+
+type gcmStreamReaderAt struct {
+	r        io.ReaderAt
+	s        *gcmStream
+	segments int64
+	ctSize   int64
+	buffers  sync.Pool  // of *gcmStreamSegment, so that concurrent reads decrypt in parallel
+	mu       sync.Mutex // guards last
+	last     *gcmStreamSegment
+}
+
+// gcmStreamSegment holds the buffers of a segment, pt being its authenticated
+// plaintext once opened.
+type gcmStreamSegment struct {
+	index int64
+	ct    []byte
+	pt    []byte
+}
+
+// Returns a reader decrypting at any offset a stream of size bytes written by
+// NewStreamWriter. Only the segments covering a read are decrypted and
+// authenticated, a truncated stream is detected when its last segment is read.
+func (gcm) NewStreamReaderAt(r io.ReaderAt, size int64, key, additionalData []byte) (*io.SectionReader, error) {
+	if size < int64(gcmStreamHeaderSize) {
+		return nil, InvalidCiphertextError(size)
+	}
+	if err := ValidKeySize(len(key)); err != nil {
+		return nil, err
+	}
+	header := make([]byte, gcmStreamHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	s, err := newGCMStream(key, header, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	overhead := int64(s.aead.Overhead())
+	segCtSize := int64(s.segmentSize) + overhead
+	ctSize := size - int64(gcmStreamHeaderSize)
+	segments := (ctSize + segCtSize - 1) / segCtSize
+	if segments == 0 || ctSize-(segments-1)*segCtSize < overhead {
+		return nil, ErrAuthentication
+	}
+	ra := &gcmStreamReaderAt{r: r, s: s, segments: segments, ctSize: ctSize}
+	ra.buffers.New = func() any {
+		return &gcmStreamSegment{ct: make([]byte, segCtSize), pt: make([]byte, 0, s.segmentSize)}
+	}
+	return io.NewSectionReader(ra, 0, ctSize-segments*overhead), nil
+}
+
+// ReadAt decrypts the segments covering p, the last one authenticated being
+// kept so that consecutive small reads do not open it again.
+func (sr *gcmStreamReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, InvalidCiphertextError(off)
+	}
+	segSize := int64(sr.s.segmentSize)
+	n := 0
+	for n < len(p) {
+		index := (off + int64(n)) / segSize
+		if index >= sr.segments {
+			return n, io.EOF
+		}
+		c, err := sr.readSegment(p[n:], index, off+int64(n)-index*segSize)
+		if err != nil {
+			return n, err
+		}
+		if c == 0 {
+			return n, io.EOF
+		}
+		n += c
+	}
+	return n, nil
+}
+
+// readSegment copies to p the plaintext of the segment index from offset rel.
+func (sr *gcmStreamReaderAt) readSegment(p []byte, index, rel int64) (int, error) {
+	sr.mu.Lock()
+	if last := sr.last; last != nil && last.index == index {
+		c := copy(p, last.pt[rel:])
+		sr.mu.Unlock()
+		return c, nil
+	}
+	sr.mu.Unlock()
+	segCtSize := int64(sr.s.segmentSize + sr.s.aead.Overhead())
+	start := index * segCtSize
+	end := min(start+segCtSize, sr.ctSize)
+	seg := sr.buffers.Get().(*gcmStreamSegment)
+	if _, err := sr.r.ReadAt(seg.ct[:end-start], int64(gcmStreamHeaderSize)+start); err != nil && err != io.EOF {
+		sr.buffers.Put(seg)
+		return 0, err
+	}
+	var err error
+	if seg.pt, err = sr.s.open(seg.pt[:0], seg.ct[:end-start], uint64(index), index == sr.segments-1); err != nil {
+		sr.buffers.Put(seg)
+		return 0, err
+	}
+	seg.index = index
+	c := copy(p, seg.pt[rel:])
+	sr.mu.Lock()
+	prev := sr.last
+	sr.last = seg
+	sr.mu.Unlock()
+	if prev != nil {
+		sr.buffers.Put(prev)
+	}
+	return c, nil
+}
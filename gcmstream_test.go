@@ -0,0 +1,373 @@
+package aes
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSegmentSize = 16
+
+// sealStream writes input through a stream writer with small segments.
+func sealStream(t *testing.T, key, ad, input []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := GCM.NewStreamWriter(&buf, key, ad, testSegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writeChunks(w, input); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func openStream(key, ad, stream []byte) ([]byte, error) {
+	r, err := GCM.NewStreamReader(bytes.NewReader(stream), key, ad)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// streamSegments splits a stream into its header and sealed segments.
+func streamSegments(stream []byte) (header []byte, segments [][]byte) {
+	header, stream = stream[:gcmStreamHeaderSize], stream[gcmStreamHeaderSize:]
+	for len(stream) > 0 {
+		n := min(testSegmentSize+16, len(stream))
+		segments = append(segments, stream[:n])
+		stream = stream[n:]
+	}
+	return header, segments
+}
+
+func joinStream(header []byte, segments ...[]byte) []byte {
+	return bytes.Join(append([][]byte{header}, segments...), nil)
+}
+
+func TestGCMStreamRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	ad := []byte("stream header")
+	input := bytes.Repeat([]byte("0123456789"), 10)
+	for _, n := range []int{0, 1, 15, 16, 17, 32, 48, 100} {
+		stream := sealStream(t, key, ad, input[:n])
+		segments := max(1, (n+testSegmentSize-1)/testSegmentSize)
+		if want := gcmStreamHeaderSize + n + 16*segments; len(stream) != want {
+			t.Errorf("%d bytes: stream of %d bytes, want %d", n, len(stream), want)
+		}
+		got, err := openStream(key, ad, stream)
+		if err != nil || !bytes.Equal(got, input[:n]) {
+			t.Errorf("%d bytes: got %q, %v", n, got, err)
+		}
+		if _, err = openStream(key, []byte("other"), stream); err != ErrAuthentication {
+			t.Errorf("%d bytes: wrong additional data got %v", n, err)
+		}
+	}
+	if _, err := GCM.NewStreamWriter(io.Discard, key, nil, testSegmentSize-1); err != GCMStreamSegmentSizeError(testSegmentSize-1) {
+		t.Errorf("small segment got %v", err)
+	}
+}
+
+func TestGCMStreamTampering(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	input := bytes.Repeat([]byte("segment!"), 8) // 4 full segments
+	header, segments := streamSegments(sealStream(t, key, nil, input))
+	if len(segments) != 4 {
+		t.Fatalf("got %d segments", len(segments))
+	}
+	badHeader := bytes.Clone(header)
+	badHeader[len(badHeader)-1] ^= 1
+	for _, tt := range []struct {
+		name   string
+		stream []byte
+	}{
+		{"truncated at a segment boundary", joinStream(header, segments[:3]...)},
+		{"truncated to the header", header},
+		{"truncated within a segment", joinStream(header, segments[0], segments[1], segments[2][:20])},
+		{"reordered segments", joinStream(header, segments[1], segments[0], segments[2], segments[3])},
+		{"duplicated segment", joinStream(header, segments[0], segments[0], segments[1], segments[2], segments[3])},
+		{"appended segment", joinStream(header, append(segments, segments[3])...)},
+		{"modified header", joinStream(badHeader, segments...)},
+	} {
+		got, err := openStream(key, nil, tt.stream)
+		if err != ErrAuthentication {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+		// Only the segments authenticated before the failure are returned.
+		if !bytes.HasPrefix(input, got) {
+			t.Errorf("%s: returned unauthenticated data %q", tt.name, got)
+		}
+	}
+	if _, err := openStream(key, nil, header[:5]); err != InvalidCiphertextError(5) {
+		t.Errorf("short header got %v", err)
+	}
+}
+
+func TestGCMStreamLastFlag(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	input := bytes.Repeat([]byte("x"), 2*testSegmentSize)
+	header, segments := streamSegments(sealStream(t, key, nil, input))
+	s, err := newGCMStream(key, header, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The writer flags only the last segment.
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		if _, err := s.open(nil, seg, uint64(i), last); err != nil {
+			t.Errorf("segment %d: %v", i, err)
+		}
+		if _, err := s.open(nil, seg, uint64(i), !last); err != ErrAuthentication {
+			t.Errorf("segment %d with the flag flipped: got %v", i, err)
+		}
+	}
+	// A last segment resealed without the flag makes the stream look truncated.
+	unflagged := s.seal(nil, input[testSegmentSize:], 1, false)
+	if _, err := openStream(key, nil, joinStream(header, segments[0], unflagged)); err != ErrAuthentication {
+		t.Errorf("unflagged last segment got %v", err)
+	}
+	// So does a flagged segment followed by more data.
+	flagged := s.seal(nil, input[:testSegmentSize], 0, true)
+	if _, err := openStream(key, nil, joinStream(header, flagged, segments[1])); err != ErrAuthentication {
+		t.Errorf("flagged first segment got %v", err)
+	}
+	if got, err := openStream(key, nil, joinStream(header, flagged)); err != nil || !bytes.Equal(got, input[:testSegmentSize]) {
+		t.Errorf("flagged single segment got %q, %v", got, err)
+	}
+}
+
+func TestGCMStreamCloseError(t *testing.T) {
+	errWrite := io.ErrShortWrite
+	w, err := GCM.NewStreamWriter(failingWriter{errWrite}, make([]byte, 16), nil, testSegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = w.Close(); err != errWrite {
+			t.Errorf("Close #%d got %v", i+1, err)
+		}
+	}
+}
+
+func TestGCMStreamReaderAt(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	input := make([]byte, 64*testSegmentSize+5)
+	for i := range input {
+		input[i] = byte(i * 7)
+	}
+	stream := sealStream(t, key, nil, input)
+	sr, err := GCM.NewStreamReaderAt(bytes.NewReader(stream), int64(len(stream)), key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.Size() != int64(len(input)) {
+		t.Fatalf("got size %d, want %d", sr.Size(), len(input))
+	}
+	rng := rand.New(rand.NewPCG(3, 4))
+	for i := 0; i < 500; i++ {
+		off := rng.IntN(len(input))
+		b := make([]byte, rng.IntN(100)+1)
+		n, err := sr.ReadAt(b, int64(off))
+		want := input[off:min(off+len(b), len(input))]
+		if !bytes.Equal(b[:n], want) {
+			t.Fatalf("offset %d, length %d: got %x, want %x", off, len(b), b[:n], want)
+		}
+		if n < len(b) && err != io.EOF {
+			t.Fatalf("offset %d, length %d: got %v", off, len(b), err)
+		}
+	}
+}
+
+func TestGCMStreamReaderAtConcurrent(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	input := bytes.Repeat([]byte("concurrent reads"), 64)
+	stream := sealStream(t, key, nil, input)
+	sr, err := GCM.NewStreamReaderAt(bytes.NewReader(stream), int64(len(stream)), key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(off int) {
+			defer wg.Done()
+			b := make([]byte, 40)
+			if n, err := sr.ReadAt(b, int64(off)); err != nil || !bytes.Equal(b[:n], input[off:off+40]) {
+				t.Errorf("offset %d: got %q, %v", off, b[:n], err)
+			}
+		}(g * 50)
+	}
+	wg.Wait()
+}
+
+// barrierReaderAt lets a ReadAt of the ciphertext complete only once another
+// is in progress, so that it times out when the reads are serialized.
+type barrierReaderAt struct {
+	r       io.ReaderAt
+	arrived chan struct{}
+}
+
+func (b *barrierReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(gcmStreamHeaderSize) {
+		select {
+		case b.arrived <- struct{}{}:
+		case <-b.arrived:
+		case <-time.After(5 * time.Second):
+			return 0, errors.New("ReadAt calls were serialized")
+		}
+	}
+	return b.r.ReadAt(p, off)
+}
+
+func TestGCMStreamReaderAtParallel(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	input := bytes.Repeat([]byte("parallel reads!!"), 8)
+	stream := sealStream(t, key, nil, input)
+	sr, err := GCM.NewStreamReaderAt(&barrierReaderAt{bytes.NewReader(stream), make(chan struct{})}, int64(len(stream)), key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for _, off := range []int{0, 4 * testSegmentSize} {
+		wg.Add(1)
+		go func(off int) {
+			defer wg.Done()
+			b := make([]byte, testSegmentSize)
+			if n, err := sr.ReadAt(b, int64(off)); err != nil || !bytes.Equal(b[:n], input[off:off+testSegmentSize]) {
+				t.Errorf("offset %d: got %q, %v", off, b[:n], err)
+			}
+		}(off)
+	}
+	wg.Wait()
+}
+
+// countingReaderAt counts the reads of the ciphertext.
+type countingReaderAt struct {
+	r     io.ReaderAt
+	reads atomic.Int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(gcmStreamHeaderSize) {
+		c.reads.Add(1)
+	}
+	return c.r.ReadAt(p, off)
+}
+
+func TestGCMStreamReaderAtCache(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	input := bytes.Repeat([]byte("cached segments!"), 8)
+	stream := sealStream(t, key, nil, input)
+	cr := &countingReaderAt{r: bytes.NewReader(stream)}
+	sr, err := GCM.NewStreamReaderAt(cr, int64(len(stream)), key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 1)
+	for _, tt := range []struct {
+		off   int
+		reads int64
+	}{
+		{0, 1},
+		{1, 1},
+		{testSegmentSize - 1, 1},
+		{testSegmentSize, 2},
+		{testSegmentSize + 5, 2},
+		{3, 3},
+		{3*testSegmentSize + 2, 4},
+		{3*testSegmentSize + 2, 4},
+	} {
+		if _, err := sr.ReadAt(b, int64(tt.off)); err != nil || b[0] != input[tt.off] {
+			t.Errorf("offset %d: got %q, %v, want %q", tt.off, b, err, input[tt.off])
+		}
+		if got := cr.reads.Load(); got != tt.reads {
+			t.Errorf("offset %d: got %d segment reads, want %d", tt.off, got, tt.reads)
+		}
+	}
+}
+
+func TestGCMStreamReaderAtTampering(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	input := bytes.Repeat([]byte("x"), 64*testSegmentSize)
+	stream := sealStream(t, key, nil, input)
+	// Tamper with segment 50, far from offset 0.
+	const tampered = 50
+	bad := bytes.Clone(stream)
+	bad[gcmStreamHeaderSize+tampered*(testSegmentSize+16)+3] ^= 1
+	sr, err := GCM.NewStreamReaderAt(bytes.NewReader(bad), int64(len(bad)), key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, testSegmentSize)
+	for i := 0; i < 64; i++ {
+		var want error
+		if i == tampered {
+			want = ErrAuthentication
+		}
+		if _, err := sr.ReadAt(b, int64(i*testSegmentSize)); err != want {
+			t.Errorf("segment %d: got %v, want %v", i, err, want)
+		}
+	}
+	// A read spanning the tampered segment returns the data before it.
+	b = make([]byte, 3*testSegmentSize)
+	n, err := sr.ReadAt(b, (tampered-1)*testSegmentSize)
+	if err != ErrAuthentication || n != testSegmentSize {
+		t.Errorf("spanning read got %d bytes, %v", n, err)
+	}
+	// A stream truncated at a segment boundary fails once its new last segment is read.
+	short := stream[:len(stream)-(testSegmentSize+16)]
+	sr, err = GCM.NewStreamReaderAt(bytes.NewReader(short), int64(len(short)), key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sr.ReadAt(b[:1], 0); err != nil {
+		t.Errorf("first segment of a truncated stream: %v", err)
+	}
+	if _, err = sr.ReadAt(b[:1], sr.Size()-1); err != ErrAuthentication {
+		t.Errorf("last segment of a truncated stream: got %v", err)
+	}
+}
+
+// BenchmarkGCMStreamReaderAtParallel reads 4 KiB at random offsets of a 16 MiB
+// stream in 64 KiB segments from every goroutine.
+func BenchmarkGCMStreamReaderAtParallel(b *testing.B) {
+	key := bytes.Repeat([]byte{1}, 16)
+	input := make([]byte, 16<<20)
+	var buf bytes.Buffer
+	w, err := GCM.NewStreamWriter(&buf, key, nil, 64<<10)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err = w.Write(input); err != nil {
+		b.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		b.Fatal(err)
+	}
+	stream := buf.Bytes()
+	sr, err := GCM.NewStreamReaderAt(bytes.NewReader(stream), int64(len(stream)), key, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	const readSize = 4 << 10
+	b.SetBytes(readSize)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		p := make([]byte, readSize)
+		rng := rand.New(rand.NewPCG(rand.Uint64(), 0))
+		for pb.Next() {
+			if _, err := sr.ReadAt(p, rng.Int64N(int64(len(input)-readSize))); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
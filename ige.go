@@ -0,0 +1,97 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/colduction/aes/padding"
+)
+
+type IGEIvSizeError int
+
+func (i IGEIvSizeError) Error() string {
+	return fmt.Sprintf("aes-ige: iv size is not twice the block size: %d", int(i))
+}
+
+// ValidIvSize checks that the iv holds the two block-sized halves, the previous ciphertext and plaintext blocks.
+func (ige) ValidIvSize(length, blocksize int) error {
+	if err := ValidIvSize(length); err != nil {
+		return err
+	}
+	if err := ValidBlockSize(blocksize); err != nil {
+		return err
+	}
+	if length != 2*blocksize {
+		return IGEIvSizeError(length)
+	}
+	return nil
+}
+
+// Encrypts input using AES in IGE mode, the iv must be twice the block size
+func (ige) Encrypt(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	if err = IGE.ValidIvSize(len(iv), bs); err != nil {
+		return nil, err
+	}
+	if pad != nil {
+		if input, err = pad.Pad(input, bs); err != nil {
+			return nil, err
+		}
+		lenInput = len(input)
+	}
+	if lenInput%bs != 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	ct := make([]byte, lenInput)
+	yPrev, xPrev := iv[:bs], iv[bs:]
+	for s, e := 0, bs; s < lenInput; s, e = e, e+bs {
+		subtle.XORBytes(ct[s:e], input[s:e], yPrev)
+		block.Encrypt(ct[s:e], ct[s:e])
+		subtle.XORBytes(ct[s:e], ct[s:e], xPrev)
+		yPrev, xPrev = ct[s:e], input[s:e]
+	}
+	return ct, nil
+}
+
+// Decrypts ciphertext using AES in IGE mode, the iv must be twice the block size
+func (ige) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	if lenCt%bs != 0 {
+		return nil, InvalidDataError(lenCt)
+	}
+	if err = IGE.ValidIvSize(len(iv), bs); err != nil {
+		return nil, err
+	}
+	pt := make([]byte, lenCt)
+	yPrev, xPrev := iv[:bs], iv[bs:]
+	for s, e := 0, bs; s < lenCt; s, e = e, e+bs {
+		subtle.XORBytes(pt[s:e], ciphertext[s:e], xPrev)
+		block.Decrypt(pt[s:e], pt[s:e])
+		subtle.XORBytes(pt[s:e], pt[s:e], yPrev)
+		yPrev, xPrev = ciphertext[s:e], pt[s:e]
+	}
+	if pad != nil {
+		pt, err = pad.Unpad(pt, bs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pt, nil
+}
@@ -0,0 +1,88 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Test vectors from the OpenSSL IGE test suite (test/igetest.c).
+var igeTests = []struct {
+	key, iv, plaintext, ciphertext string
+}{
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"1a8519a6557be652e9da8e43da4ef4453cf456b4ca488aa383c79c98b34797cb",
+	},
+	{
+		"5468697320697320616e20696d706c65",
+		"6d656e746174696f6e206f6620494745206d6f646520666f72204f70656e5353",
+		"99706487a1cde613bc6de0b6f24b1c7aa448c8b9c3403e3467a8cad89340f53b",
+		"4c2e204c6574277320686f70652042656e20676f74206974207269676874210a",
+	},
+}
+
+func TestIGEVectors(t *testing.T) {
+	for i, tt := range igeTests {
+		key, iv, pt, want := fromHex(t, tt.key), fromHex(t, tt.iv), fromHex(t, tt.plaintext), fromHex(t, tt.ciphertext)
+		ct, err := IGE.Encrypt(pt, key, iv, nil)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !bytes.Equal(ct, want) {
+			t.Errorf("#%d: got %x, want %x", i, ct, want)
+		}
+		got, err := IGE.Decrypt(ct, key, iv, nil)
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("#%d: decrypt got %x, %v", i, got, err)
+		}
+	}
+	key := fromHex(t, igeTests[0].key)
+	iv := fromHex(t, igeTests[0].iv)
+	if _, err := IGE.Encrypt(make([]byte, 32), key, iv[:16], nil); err != IGEIvSizeError(16) {
+		t.Errorf("one-block IV got %v", err)
+	}
+}
+
+func TestIGEErrorPropagation(t *testing.T) {
+	key := fromHex(t, igeTests[0].key)
+	iv := bytes.Repeat([]byte{3}, 32)
+	msg := bytes.Repeat([]byte("igeigeigeigeigei"), 6)
+	ct, err := IGE.Encrypt(msg, key, iv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A ciphertext bit flip garbles its own block and every later one.
+	tampered := bytes.Clone(ct)
+	tampered[40] ^= 1
+	pt, err := IGE.Decrypt(tampered, key, iv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pt[:32], msg[:32]) {
+		t.Errorf("blocks before the flip changed: %x", pt[:32])
+	}
+	for i := 32; i < len(msg); i += 16 {
+		if bytes.Equal(pt[i:i+16], msg[i:i+16]) {
+			t.Errorf("block %d not garbled", i/16)
+		}
+	}
+
+	// A plaintext bit flip changes its own ciphertext block and every later one.
+	changed := bytes.Clone(msg)
+	changed[17] ^= 1
+	ct2, err := IGE.Encrypt(changed, key, iv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ct2[:16], ct[:16]) {
+		t.Error("block before the flip changed")
+	}
+	for i := 16; i < len(ct); i += 16 {
+		if bytes.Equal(ct2[i:i+16], ct[i:i+16]) {
+			t.Errorf("ciphertext block %d unchanged", i/16)
+		}
+	}
+}
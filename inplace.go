@@ -0,0 +1,43 @@
+package aes
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/colduction/aes/padding"
+)
+
+// scratchPool holds the counter, keystream and feedback blocks of the
+// EncryptTo and DecryptTo functions, sized for the largest Rijndael block.
+var scratchPool = sync.Pool{New: func() any { return new([64]byte) }}
+
+// appendPadded appends input padded with pad to dst, without allocating when
+// dst has room for input and a block more.
+func appendPadded(dst, input []byte, pad padding.Padding, blocksize int) ([]byte, error) {
+	if pad == nil {
+		return append(dst, input...), nil
+	}
+	ret := append(slices.Grow(dst, len(input)+blocksize), input...)
+	padded, err := padding.PadInPlace(pad, ret[len(dst):], blocksize)
+	if err != nil {
+		return nil, err
+	}
+	// A padding defined outside the package may have returned a new slice.
+	if len(padded) > 0 && &padded[0] != &ret[len(dst)] {
+		return append(ret[:len(dst)], padded...), nil
+	}
+	return ret[:len(dst)+len(padded)], nil
+}
+
+// unpadAppended removes the padding from the plaintext appended to dst in ret.
+func unpadAppended(ret []byte, lenDst int, pad padding.Padding, blocksize int) ([]byte, error) {
+	if pad == nil {
+		return ret, nil
+	}
+	pt, err := pad.Unpad(ret[lenDst:], blocksize)
+	if err != nil {
+		return nil, err
+	}
+	// Unpad may return a subslice not starting at the beginning of the data.
+	return append(ret[:lenDst], pt...), nil
+}
@@ -0,0 +1,104 @@
+package aes
+
+import (
+	"bytes"
+	stdaes "crypto/aes"
+	"testing"
+
+	"github.com/colduction/aes/padding"
+)
+
+type toFuncs struct {
+	name             string
+	encrypt, decrypt func(dst, src []byte) ([]byte, error)
+}
+
+func keyedToFuncs(key, iv []byte, pad padding.Padding) []toFuncs {
+	return []toFuncs{
+		{"CBC", func(d, s []byte) ([]byte, error) { return CBC.EncryptTo(d, s, key, iv, pad) }, func(d, s []byte) ([]byte, error) { return CBC.DecryptTo(d, s, key, iv, pad) }},
+		{"CFB", func(d, s []byte) ([]byte, error) { return CFB.EncryptTo(d, s, key, iv, pad) }, func(d, s []byte) ([]byte, error) { return CFB.DecryptTo(d, s, key, iv, pad) }},
+		{"CTR", func(d, s []byte) ([]byte, error) { return CTR.EncryptTo(d, s, key, iv, pad) }, func(d, s []byte) ([]byte, error) { return CTR.DecryptTo(d, s, key, iv, pad) }},
+		{"ECB", func(d, s []byte) ([]byte, error) { return ECB.EncryptTo(d, s, key, pad) }, func(d, s []byte) ([]byte, error) { return ECB.DecryptTo(d, s, key, pad) }},
+		{"OFB", func(d, s []byte) ([]byte, error) { return OFB.EncryptTo(d, s, key, iv, pad) }, func(d, s []byte) ([]byte, error) { return OFB.DecryptTo(d, s, key, iv, pad) }},
+	}
+}
+
+func cipherToFuncs(c *Cipher, iv []byte, pad padding.Padding) []toFuncs {
+	return []toFuncs{
+		{"CBC", func(d, s []byte) ([]byte, error) { return c.EncryptCBCTo(d, s, iv, pad) }, func(d, s []byte) ([]byte, error) { return c.DecryptCBCTo(d, s, iv, pad) }},
+		{"CFB", func(d, s []byte) ([]byte, error) { return c.EncryptCFBTo(d, s, iv, pad) }, func(d, s []byte) ([]byte, error) { return c.DecryptCFBTo(d, s, iv, pad) }},
+		{"CTR", func(d, s []byte) ([]byte, error) { return c.EncryptCTRTo(d, s, iv, pad) }, func(d, s []byte) ([]byte, error) { return c.DecryptCTRTo(d, s, iv, pad) }},
+		{"ECB", func(d, s []byte) ([]byte, error) { return c.EncryptECBTo(d, s, pad) }, func(d, s []byte) ([]byte, error) { return c.DecryptECBTo(d, s, pad) }},
+		{"OFB", func(d, s []byte) ([]byte, error) { return c.EncryptOFBTo(d, s, iv, pad) }, func(d, s []byte) ([]byte, error) { return c.DecryptOFBTo(d, s, iv, pad) }},
+	}
+}
+
+func TestToMatchesOneShot(t *testing.T) {
+	key := bytes.Repeat([]byte{2}, 16)
+	iv := bytes.Repeat([]byte{3}, 16)
+	input := []byte("appending to dst gives the one-shot output")
+	want := map[string][]byte{}
+	want["CBC"], _ = CBC.Encrypt(input, key, iv, padding.PKCS7)
+	want["CFB"], _ = CFB.Encrypt(input, key, iv, padding.PKCS7)
+	want["CTR"], _ = CTR.Encrypt(input, key, iv, padding.PKCS7)
+	want["ECB"], _ = ECB.Encrypt(input, key, padding.PKCS7)
+	want["OFB"], _ = OFB.Encrypt(input, key, iv, padding.PKCS7)
+	c, _ := New(key)
+	prefix := []byte("prefix")
+	for _, fs := range append(keyedToFuncs(key, iv, padding.PKCS7), cipherToFuncs(c, iv, padding.PKCS7)...) {
+		ct, err := fs.encrypt(bytes.Clone(prefix), input)
+		if err != nil || !bytes.Equal(ct, append(bytes.Clone(prefix), want[fs.name]...)) {
+			t.Errorf("%s: encrypt got %x, %v", fs.name, ct, err)
+			continue
+		}
+		pt, err := fs.decrypt(bytes.Clone(prefix), ct[len(prefix):])
+		if err != nil || !bytes.Equal(pt, append(bytes.Clone(prefix), input...)) {
+			t.Errorf("%s: decrypt got %q, %v", fs.name, pt, err)
+		}
+		// Encrypting and decrypting in place.
+		buf := append(make([]byte, 0, len(input)+16), input...)
+		ct, err = fs.encrypt(buf[:0], buf)
+		if err != nil || !bytes.Equal(ct, want[fs.name]) {
+			t.Errorf("%s: in-place encrypt got %x, %v", fs.name, ct, err)
+			continue
+		}
+		if pt, err = fs.decrypt(ct[:0], ct); err != nil || !bytes.Equal(pt, input) {
+			t.Errorf("%s: in-place decrypt got %q, %v", fs.name, pt, err)
+		}
+	}
+}
+
+func TestToAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not stable with -race")
+	}
+	key := bytes.Repeat([]byte{2}, 16)
+	iv := bytes.Repeat([]byte{3}, 16)
+	input := bytes.Repeat([]byte{4}, 100)
+	dst := make([]byte, 0, len(input)+16)
+	ct := make([]byte, 0, len(input)+16)
+	// The key-based forms allocate the key schedule and nothing else.
+	keyAllocs := testing.AllocsPerRun(100, func() { stdaes.NewCipher(key) })
+	c, _ := New(key)
+	for _, tt := range []struct {
+		kind   string
+		funcs  []toFuncs
+		allocs float64
+	}{
+		{"key", keyedToFuncs(key, iv, padding.PKCS7), keyAllocs},
+		{"Cipher", cipherToFuncs(c, iv, padding.PKCS7), 0},
+	} {
+		for _, fs := range tt.funcs {
+			var err error
+			if ct, err = fs.encrypt(ct[:0], input); err != nil {
+				t.Fatal(err)
+			}
+			if n := testing.AllocsPerRun(100, func() { fs.encrypt(dst[:0], input) }); n != tt.allocs {
+				t.Errorf("%s %s encrypt: %v allocations, want %v", tt.kind, fs.name, n, tt.allocs)
+			}
+			if n := testing.AllocsPerRun(100, func() { fs.decrypt(dst[:0], ct) }); n != tt.allocs {
+				t.Errorf("%s %s decrypt: %v allocations, want %v", tt.kind, fs.name, n, tt.allocs)
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	kwSemiblockSize int = 8
+	kwAIVSize       int = 4
+)
+
+var (
+	kwDefaultIV  = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6} // RFC 3394 section 2.2.3.1
+	kwDefaultAIV = []byte{0xa6, 0x59, 0x59, 0xa6}                         // RFC 5649 section 3
+)
+
+type (
+	KWDataSizeError  int
+	KWIntegrityError int
+	KWIvSizeError    int
+)
+
+func (i KWDataSizeError) Error() string {
+	return fmt.Sprintf("aes-kw: invalid data size %d", int(i))
+}
+
+func (i KWIntegrityError) Error() string {
+	return fmt.Sprintf("aes-kw: integrity check failed for wrapped data of size %d", int(i))
+}
+
+func (i KWIvSizeError) Error() string {
+	return fmt.Sprintf("aes-kw: invalid initial value size %d", int(i))
+}
+
+// kwWrap applies the wrapping process W to the semiblocks r under the initial value a.
+func kwWrap(block cipher.Block, a, r []byte) []byte {
+	n := len(r) / kwSemiblockSize
+	out := make([]byte, kwSemiblockSize+len(r))
+	copy(out[kwSemiblockSize:], r)
+	var b [2 * kwSemiblockSize]byte
+	copy(b[:kwSemiblockSize], a)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			ri := out[kwSemiblockSize*i : kwSemiblockSize*(i+1)]
+			copy(b[kwSemiblockSize:], ri)
+			block.Encrypt(b[:], b[:])
+			binary.BigEndian.PutUint64(b[:kwSemiblockSize], binary.BigEndian.Uint64(b[:kwSemiblockSize])^uint64(n*j+i))
+			copy(ri, b[kwSemiblockSize:])
+		}
+	}
+	copy(out, b[:kwSemiblockSize])
+	return out
+}
+
+// kwUnwrap applies the unwrapping process W^-1 and returns the recovered initial value and semiblocks.
+func kwUnwrap(block cipher.Block, c []byte) (a, r []byte) {
+	n := len(c)/kwSemiblockSize - 1
+	r = make([]byte, len(c)-kwSemiblockSize)
+	copy(r, c[kwSemiblockSize:])
+	var b [2 * kwSemiblockSize]byte
+	copy(b[:kwSemiblockSize], c)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			ri := r[kwSemiblockSize*(i-1) : kwSemiblockSize*i]
+			binary.BigEndian.PutUint64(b[:kwSemiblockSize], binary.BigEndian.Uint64(b[:kwSemiblockSize])^uint64(n*j+i))
+			copy(b[kwSemiblockSize:], ri)
+			block.Decrypt(b[:], b[:])
+			copy(ri, b[kwSemiblockSize:])
+		}
+	}
+	return b[:kwSemiblockSize], r
+}
+
+// Wraps input under kek with the default integrity check value (RFC 3394)
+func (kw) Wrap(input, kek []byte) ([]byte, error) {
+	return KW.WrapWithIV(input, kek, kwDefaultIV)
+}
+
+// Wraps input under kek with a custom 8 bytes integrity check value (RFC 3394)
+func (kw) WrapWithIV(input, kek, iv []byte) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput < 2*kwSemiblockSize || lenInput%kwSemiblockSize != 0 {
+		return nil, KWDataSizeError(lenInput)
+	}
+	if len(iv) != kwSemiblockSize {
+		return nil, KWIvSizeError(len(iv))
+	}
+	block, err := stdaes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return kwWrap(block, iv, input), nil
+}
+
+// Unwraps ciphertext under kek and verifies the default integrity check value (RFC 3394)
+func (kw) Unwrap(ciphertext, kek []byte) ([]byte, error) {
+	return KW.UnwrapWithIV(ciphertext, kek, kwDefaultIV)
+}
+
+// Unwraps ciphertext under kek and verifies a custom 8 bytes integrity check value (RFC 3394)
+func (kw) UnwrapWithIV(ciphertext, kek, iv []byte) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt < 3*kwSemiblockSize || lenCt%kwSemiblockSize != 0 {
+		return nil, KWDataSizeError(lenCt)
+	}
+	if len(iv) != kwSemiblockSize {
+		return nil, KWIvSizeError(len(iv))
+	}
+	block, err := stdaes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	a, pt := kwUnwrap(block, ciphertext)
+	if subtle.ConstantTimeCompare(a, iv) != 1 {
+		clear(pt)
+		return nil, KWIntegrityError(lenCt)
+	}
+	return pt, nil
+}
+
+// Wraps input of any size under kek with the default alternative initial value (RFC 5649)
+func (kw) WrapPad(input, kek []byte) ([]byte, error) {
+	return KW.WrapPadWithIV(input, kek, kwDefaultAIV)
+}
+
+// Wraps input of any size under kek with a custom 4 bytes alternative initial value (RFC 5649)
+func (kw) WrapPadWithIV(input, kek, aiv []byte) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 || uint64(lenInput) > math.MaxUint32 {
+		return nil, KWDataSizeError(lenInput)
+	}
+	if len(aiv) != kwAIVSize {
+		return nil, KWIvSizeError(len(aiv))
+	}
+	block, err := stdaes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	a := make([]byte, kwSemiblockSize)
+	copy(a, aiv)
+	binary.BigEndian.PutUint32(a[kwAIVSize:], uint32(lenInput))
+	padded := make([]byte, (lenInput+kwSemiblockSize-1)/kwSemiblockSize*kwSemiblockSize)
+	copy(padded, input)
+	if len(padded) == kwSemiblockSize {
+		// A single semiblock is encrypted together with the initial value in one block.
+		ct := append(a, padded...)
+		block.Encrypt(ct, ct)
+		return ct, nil
+	}
+	return kwWrap(block, a, padded), nil
+}
+
+// Unwraps ciphertext under kek and verifies the default alternative initial value (RFC 5649)
+func (kw) UnwrapPad(ciphertext, kek []byte) ([]byte, error) {
+	return KW.UnwrapPadWithIV(ciphertext, kek, kwDefaultAIV)
+}
+
+// Unwraps ciphertext under kek and verifies a custom 4 bytes alternative initial value (RFC 5649)
+func (kw) UnwrapPadWithIV(ciphertext, kek, aiv []byte) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt < 2*kwSemiblockSize || lenCt%kwSemiblockSize != 0 {
+		return nil, KWDataSizeError(lenCt)
+	}
+	if len(aiv) != kwAIVSize {
+		return nil, KWIvSizeError(len(aiv))
+	}
+	block, err := stdaes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	var a, pt []byte
+	if lenCt == 2*kwSemiblockSize {
+		b := make([]byte, lenCt)
+		block.Decrypt(b, ciphertext)
+		a, pt = b[:kwSemiblockSize], b[kwSemiblockSize:]
+	} else {
+		a, pt = kwUnwrap(block, ciphertext)
+	}
+	mli := int(binary.BigEndian.Uint32(a[kwAIVSize:]))
+	lenPt := len(pt)
+	ok := subtle.ConstantTimeCompare(a[:kwAIVSize], aiv)
+	if mli <= lenPt-kwSemiblockSize || mli > lenPt {
+		ok = 0
+	} else {
+		// The padding is at most 7 bytes and must be zero.
+		var nonzero byte
+		for _, b := range pt[mli:] {
+			nonzero |= b
+		}
+		ok &= subtle.ConstantTimeByteEq(nonzero, 0)
+	}
+	if ok != 1 {
+		clear(pt)
+		return nil, KWIntegrityError(lenCt)
+	}
+	return pt[:mli], nil
+}
@@ -0,0 +1,102 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+// RFC 3394 section 4.
+var kwTests = []struct {
+	kek, key, ciphertext string
+}{
+	{"000102030405060708090a0b0c0d0e0f", "00112233445566778899aabbccddeeff", "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5"},
+	{"000102030405060708090a0b0c0d0e0f1011121314151617", "00112233445566778899aabbccddeeff", "96778b25ae6ca435f92b5b97c050aed2468ab8a17ad84e5d"},
+	{"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "00112233445566778899aabbccddeeff", "64e8c3f9ce0f5ba263e9777905818a2a93c8191e7d6e8ae7"},
+	{"000102030405060708090a0b0c0d0e0f1011121314151617", "00112233445566778899aabbccddeeff0001020304050607", "031d33264e15d33268f24ec260743edce1c6c7ddee725a936ba814915c6762d2"},
+	{"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "00112233445566778899aabbccddeeff0001020304050607", "a8f9bc1612c68b3ff6e6f4fbe30e71e4769c8b80a32cb8958cd5d17d6b254da1"},
+	{"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "00112233445566778899aabbccddeeff000102030405060708090a0b0c0d0e0f", "28c9f404c4b810f4cbccb35cfb87f8263f5786e2d80ed326cbc7f0e71a99f43bfb988b9b7a02dd21"},
+}
+
+func TestKWVectors(t *testing.T) {
+	for i, tt := range kwTests {
+		kek, key, want := fromHex(t, tt.kek), fromHex(t, tt.key), fromHex(t, tt.ciphertext)
+		ct, err := KW.Wrap(key, kek)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !bytes.Equal(ct, want) {
+			t.Errorf("#%d: got %x, want %x", i, ct, want)
+		}
+		got, err := KW.Unwrap(ct, kek)
+		if err != nil || !bytes.Equal(got, key) {
+			t.Errorf("#%d: unwrap got %x, %v", i, got, err)
+		}
+		// Any change to the ciphertext corrupts the recovered ICV.
+		ct[3] ^= 1
+		if _, err := KW.Unwrap(ct, kek); err != KWIntegrityError(len(ct)) {
+			t.Errorf("#%d: tampered ICV got %v", i, err)
+		}
+	}
+}
+
+// RFC 5649 section 6.
+var kwPadTests = []struct {
+	key, ciphertext string
+}{
+	{"c37b7e6492584340bed12207808941155068f738", "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a"},
+	{"466f7250617369", "afbeb0f07dfbf5419200f2ccb50bb24f"},
+}
+
+func TestKWPadVectors(t *testing.T) {
+	kek := fromHex(t, "5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+	for i, tt := range kwPadTests {
+		key, want := fromHex(t, tt.key), fromHex(t, tt.ciphertext)
+		ct, err := KW.WrapPad(key, kek)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !bytes.Equal(ct, want) {
+			t.Errorf("#%d: got %x, want %x", i, ct, want)
+		}
+		got, err := KW.UnwrapPad(ct, kek)
+		if err != nil || !bytes.Equal(got, key) {
+			t.Errorf("#%d: unwrap got %x, %v", i, got, err)
+		}
+		ct[0] ^= 1
+		if _, err := KW.UnwrapPad(ct, kek); err != KWIntegrityError(len(ct)) {
+			t.Errorf("#%d: tampered AIV got %v", i, err)
+		}
+	}
+}
+
+func TestKWCustomIV(t *testing.T) {
+	kek := fromHex(t, kwTests[0].kek)
+	iv := []byte("12345678")
+	ct, err := KW.WrapWithIV(make([]byte, 16), kek, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := KW.UnwrapWithIV(ct, kek, iv); err != nil {
+		t.Error(err)
+	}
+	if _, err := KW.Unwrap(ct, kek); err != KWIntegrityError(len(ct)) {
+		t.Errorf("default ICV got %v", err)
+	}
+	if _, err := KW.WrapWithIV(make([]byte, 16), kek, iv[:4]); err != KWIvSizeError(4) {
+		t.Errorf("short ICV got %v", err)
+	}
+	aiv := []byte{1, 2, 3, 4}
+	ct, err = KW.WrapPadWithIV([]byte("abcdefghijk"), kek, aiv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := KW.UnwrapPadWithIV(ct, kek, aiv); err != nil || string(pt) != "abcdefghijk" {
+		t.Errorf("got %q, %v", pt, err)
+	}
+	if _, err := KW.UnwrapPad(ct, kek); err != KWIntegrityError(len(ct)) {
+		t.Errorf("default AIV got %v", err)
+	}
+	if _, err := KW.Wrap(make([]byte, 12), kek); err != KWDataSizeError(12) {
+		t.Errorf("unaligned input got %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+	"math/bits"
+
+	"github.com/colduction/aes/padding"
+)
+
+const (
+	ocbBlockSize    int = 16
+	ocbMinNonceSize int = 1
+	ocbMaxNonceSize int = 15
+)
+
+type (
+	OCBNonceSizeError int
+	OCBTagSizeError   int
+)
+
+func (i OCBNonceSizeError) Error() string {
+	return fmt.Sprintf("aes-ocb: invalid nonce size %d, sizes between 1 and 15 bytes are allowed", int(i))
+}
+
+func (i OCBTagSizeError) Error() string {
+	return fmt.Sprintf("aes-ocb: incorrect tag size %d, sizes of 8, 12 and 16 bytes are allowed", int(i))
+}
+
+func (ocb) ValidNonceSize(length int) error {
+	if length < ocbMinNonceSize || length > ocbMaxNonceSize {
+		return OCBNonceSizeError(length)
+	}
+	return nil
+}
+
+func (ocb) ValidTagSize(length int) error {
+	switch length {
+	case 8, 12, 16:
+		return nil
+	}
+	return OCBTagSizeError(length)
+}
+
+// ocbKey holds the key-dependent values L_*, L_$ and L_i of OCB3.
+type ocbKey struct {
+	block   cipher.Block
+	lStar   [ocbBlockSize]byte
+	lDollar [ocbBlockSize]byte
+	l       [][ocbBlockSize]byte
+}
+
+func newOCB(block cipher.Block) *ocbKey {
+	k := &ocbKey{block: block}
+	block.Encrypt(k.lStar[:], k.lStar[:])
+	gf128Double(&k.lDollar, &k.lStar)
+	k.l = make([][ocbBlockSize]byte, 1, 8)
+	gf128Double(&k.l[0], &k.lDollar)
+	return k
+}
+
+// lNtz returns L_ntz(i), doubling further values on demand.
+func (k *ocbKey) lNtz(i int) *[ocbBlockSize]byte {
+	n := bits.TrailingZeros(uint(i))
+	for len(k.l) <= n {
+		var next [ocbBlockSize]byte
+		gf128Double(&next, &k.l[len(k.l)-1])
+		k.l = append(k.l, next)
+	}
+	return &k.l[n]
+}
+
+// initialOffset computes Offset_0 from the nonce and the tag size.
+func (k *ocbKey) initialOffset(offset *[ocbBlockSize]byte, nonce []byte, tagSize int) {
+	var n [ocbBlockSize]byte
+	n[0] = byte(tagSize*8%128) << 1
+	n[ocbBlockSize-1-len(nonce)] |= 1
+	copy(n[ocbBlockSize-len(nonce):], nonce)
+	bottom := int(n[ocbBlockSize-1] & 0x3f)
+	n[ocbBlockSize-1] &= 0xc0
+	var stretch [ocbBlockSize + 8]byte
+	k.block.Encrypt(stretch[:ocbBlockSize], n[:])
+	subtle.XORBytes(stretch[ocbBlockSize:], stretch[:8], stretch[1:9])
+	byteShift, bitShift := bottom/8, bottom%8
+	for i := range offset {
+		offset[i] = stretch[i+byteShift] << bitShift
+		if bitShift != 0 {
+			offset[i] |= stretch[i+byteShift+1] >> (8 - bitShift)
+		}
+	}
+}
+
+// hash computes HASH(K, A) over the associated data.
+func (k *ocbKey) hash(sum *[ocbBlockSize]byte, additionalData []byte) {
+	var offset, tmp [ocbBlockSize]byte
+	for i := 1; len(additionalData) >= ocbBlockSize; i++ {
+		subtle.XORBytes(offset[:], offset[:], k.lNtz(i)[:])
+		subtle.XORBytes(tmp[:], additionalData[:ocbBlockSize], offset[:])
+		k.block.Encrypt(tmp[:], tmp[:])
+		subtle.XORBytes(sum[:], sum[:], tmp[:])
+		additionalData = additionalData[ocbBlockSize:]
+	}
+	if len(additionalData) > 0 {
+		subtle.XORBytes(offset[:], offset[:], k.lStar[:])
+		tmp = [ocbBlockSize]byte{}
+		copy(tmp[:], additionalData)
+		tmp[len(additionalData)] = 0x80
+		subtle.XORBytes(tmp[:], tmp[:], offset[:])
+		k.block.Encrypt(tmp[:], tmp[:])
+		subtle.XORBytes(sum[:], sum[:], tmp[:])
+	}
+}
+
+// crypt encrypts or decrypts src into dst and computes the full tag.
+func (k *ocbKey) crypt(tag *[ocbBlockSize]byte, dst, src, nonce, additionalData []byte, tagSize int, encrypt bool) {
+	var offset, checksum, tmp [ocbBlockSize]byte
+	k.initialOffset(&offset, nonce, tagSize)
+	for i := 1; len(src) >= ocbBlockSize; i++ {
+		subtle.XORBytes(offset[:], offset[:], k.lNtz(i)[:])
+		subtle.XORBytes(tmp[:], src[:ocbBlockSize], offset[:])
+		if encrypt {
+			subtle.XORBytes(checksum[:], checksum[:], src[:ocbBlockSize])
+			k.block.Encrypt(tmp[:], tmp[:])
+			subtle.XORBytes(dst[:ocbBlockSize], tmp[:], offset[:])
+		} else {
+			k.block.Decrypt(tmp[:], tmp[:])
+			subtle.XORBytes(dst[:ocbBlockSize], tmp[:], offset[:])
+			subtle.XORBytes(checksum[:], checksum[:], dst[:ocbBlockSize])
+		}
+		dst, src = dst[ocbBlockSize:], src[ocbBlockSize:]
+	}
+	if n := len(src); n > 0 {
+		subtle.XORBytes(offset[:], offset[:], k.lStar[:])
+		k.block.Encrypt(tmp[:], offset[:])
+		plaintext := src
+		if !encrypt {
+			plaintext = dst
+		}
+		subtle.XORBytes(dst, src, tmp[:n])
+		tmp = [ocbBlockSize]byte{}
+		copy(tmp[:], plaintext[:n])
+		tmp[n] = 0x80
+		subtle.XORBytes(checksum[:], checksum[:], tmp[:])
+	}
+	subtle.XORBytes(checksum[:], checksum[:], offset[:])
+	subtle.XORBytes(checksum[:], checksum[:], k.lDollar[:])
+	k.block.Encrypt(tag[:], checksum[:])
+	var sum [ocbBlockSize]byte
+	k.hash(&sum, additionalData)
+	subtle.XORBytes(tag[:], tag[:], sum[:])
+}
+
+// Encrypts input using AES in OCB3 mode with default tag size (16), the nonce size may be between 1 and 15 bytes
+func (ocb) Encrypt(input, key, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	return OCB.EncryptWithTagSize(input, key, nonce, additionalData, ocbBlockSize, pad, dst...)
+}
+
+// Encrypts input using AES in OCB3 mode with custom tag size (8, 12 or 16), the nonce size may be between 1 and 15 bytes
+func (ocb) EncryptWithTagSize(input, key, nonce, additionalData []byte, tagSize int, pad padding.Padding, dst ...byte) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	err := OCB.ValidNonceSize(len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	if err = OCB.ValidTagSize(tagSize); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if pad != nil {
+		if input, err = pad.Pad(input, block.BlockSize()); err != nil {
+			return nil, err
+		}
+		lenInput = len(input)
+	}
+	var tag [ocbBlockSize]byte
+	ret, out := sliceForAppend(dst, lenInput+tagSize)
+	newOCB(block).crypt(&tag, out, input, nonce, additionalData, tagSize, true)
+	copy(out[lenInput:], tag[:tagSize])
+	return ret, nil
+}
+
+// Decrypts ciphertext using AES in OCB3 mode with default tag size (16)
+func (ocb) Decrypt(ciphertext, key, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error) {
+	return OCB.DecryptWithTagSize(ciphertext, key, nonce, additionalData, ocbBlockSize, pad, dst...)
+}
+
+// Decrypts ciphertext using AES in OCB3 mode with custom tag size (8, 12 or 16)
+func (ocb) DecryptWithTagSize(ciphertext, key, nonce, additionalData []byte, tagSize int, pad padding.Padding, dst ...byte) ([]byte, error) {
+	err := OCB.ValidNonceSize(len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	if err = OCB.ValidTagSize(tagSize); err != nil {
+		return nil, err
+	}
+	lenCt := len(ciphertext)
+	if lenCt < tagSize {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var tag [ocbBlockSize]byte
+	ret, pt := sliceForAppend(dst, lenCt-tagSize)
+	newOCB(block).crypt(&tag, pt, ciphertext[:lenCt-tagSize], nonce, additionalData, tagSize, false)
+	if subtle.ConstantTimeCompare(tag[:tagSize], ciphertext[lenCt-tagSize:]) != 1 {
+		clear(pt)
+		return nil, ErrAuthentication
+	}
+	if pad != nil {
+		if pt, err = pad.Unpad(pt, block.BlockSize()); err != nil {
+			return nil, err
+		}
+		ret = append(ret[:len(dst)], pt...)
+	}
+	return ret, nil
+}
@@ -0,0 +1,107 @@
+package aes
+
+import (
+	"bytes"
+	stdaes "crypto/aes"
+	"encoding/binary"
+	"testing"
+)
+
+// RFC 7253 appendix A, AES-128 key 000102...0f with a 128-bit tag.
+var ocbTests = []struct {
+	nonce, ad, plaintext, ciphertext string
+}{
+	{"bbaa99887766554433221100", "", "", "785407bfffc8ad9edcc5520ac9111ee6"},
+	{"bbaa99887766554433221101", "0001020304050607", "0001020304050607", "6820b3657b6f615a5725bda0d3b4eb3a257c9af1f8f03009"},
+	{"bbaa99887766554433221102", "0001020304050607", "", "81017f8203f081277152fade694a0a00"},
+	{"bbaa99887766554433221103", "", "0001020304050607", "45dd69f8f5aae72414054cd1f35d82760b2cd00d2f99bfa9"},
+	{"bbaa99887766554433221104", "000102030405060708090a0b0c0d0e0f", "000102030405060708090a0b0c0d0e0f", "571d535b60b277188be5147170a9a22c3ad7a4ff3835b8c5701c1ccec8fc3358"},
+}
+
+func TestOCBVectors(t *testing.T) {
+	key := fromHex(t, "000102030405060708090a0b0c0d0e0f")
+	for _, tt := range ocbTests {
+		nonce, ad, pt, want := fromHex(t, tt.nonce), fromHex(t, tt.ad), fromHex(t, tt.plaintext), fromHex(t, tt.ciphertext)
+		if len(pt) > 0 {
+			ct, err := OCB.Encrypt(pt, key, nonce, ad, nil)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.nonce, err)
+			}
+			if !bytes.Equal(ct, want) {
+				t.Errorf("%s: got %x, want %x", tt.nonce, ct, want)
+			}
+		}
+		got, err := OCB.Decrypt(want, key, nonce, ad, nil)
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("%s: decrypt got %x, %v", tt.nonce, got, err)
+		}
+		want[0] ^= 1
+		if _, err := OCB.Decrypt(want, key, nonce, ad, nil); err != ErrAuthentication {
+			t.Errorf("%s: tampered got %v", tt.nonce, err)
+		}
+	}
+}
+
+// RFC 7253 appendix A, the 96-bit tag example.
+func TestOCBTag96(t *testing.T) {
+	key := fromHex(t, "0f0e0d0c0b0a09080706050403020100")
+	nonce := fromHex(t, "bbaa9988776655443322110d")
+	data := fromHex(t, "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021222324252627")
+	want := fromHex(t, "1792a4e31e0755fb03e31b22116e6c2ddf9efd6e33d536f1a0124b0a55bae884ed93481529c76b6ad0c515f4d1cdd4fdac4f02aa")
+	ct, err := OCB.EncryptWithTagSize(data, key, nonce, data, 12, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ct, want) {
+		t.Errorf("got %x, want %x", ct, want)
+	}
+	pt, err := OCB.DecryptWithTagSize(ct, key, nonce, data, 12, nil)
+	if err != nil || !bytes.Equal(pt, data) {
+		t.Errorf("decrypt got %x, %v", pt, err)
+	}
+}
+
+// RFC 7253 appendix A, the iterated all-lengths output.
+func TestOCBAllLengths(t *testing.T) {
+	for _, tt := range []struct {
+		keySize, tagSize int
+		want             string
+	}{
+		{16, 16, "67e944d23256c5e0b6c61fa22fdf1ea2"},
+		{24, 16, "f673f2c3e7174aae7bae986ca9f29e17"},
+		{32, 16, "d90eb8e9c977c88b79dd793d7ffa161c"},
+		{16, 12, "77a3d8e73589158d25d01209"},
+		{24, 12, "05d56ead2752c86be6932c5e"},
+		{32, 12, "5458359ac23b0cba9e6330dd"},
+		{16, 8, "192c9b7bd90ba06a"},
+		{24, 8, "0066bc6e0ef34e24"},
+		{32, 8, "7d4ea5d445501cbe"},
+	} {
+		key := make([]byte, tt.keySize)
+		key[len(key)-1] = byte(tt.tagSize * 8)
+		block, err := stdaes.NewCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k := newOCB(block)
+		nonce := make([]byte, 12)
+		// seal allows the empty plaintexts that OCB.Encrypt rejects.
+		seal := func(dst []byte, n uint32, pt, ad []byte) []byte {
+			binary.BigEndian.PutUint32(nonce[8:], n)
+			var tag [ocbBlockSize]byte
+			out := make([]byte, len(pt))
+			k.crypt(&tag, out, pt, nonce, ad, tt.tagSize, true)
+			return append(append(dst, out...), tag[:tt.tagSize]...)
+		}
+		var c []byte
+		for i := uint32(0); i < 128; i++ {
+			s := make([]byte, i)
+			c = seal(c, 3*i+1, s, s)
+			c = seal(c, 3*i+2, s, nil)
+			c = seal(c, 3*i+3, nil, s)
+		}
+		if got := seal(nil, 385, nil, c); !bytes.Equal(got, fromHex(t, tt.want)) {
+			t.Errorf("AES-%d, %d-bit tag: got %x, want %s", tt.keySize*8, tt.tagSize*8, got, tt.want)
+		}
+	}
+}
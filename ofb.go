@@ -3,21 +3,36 @@ package aes
 import (
 	stdaes "crypto/aes"
 	"crypto/cipher"
+	"crypto/subtle"
+	"io"
 
 	"github.com/colduction/aes/padding"
 )
 
 // Encrypts input using AES in OFB mode
 func (ofb) Encrypt(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
-	lenInput := len(input)
-	if lenInput == 0 {
+	return OFB.EncryptWithBlockSize(input, key, iv, stdaes.BlockSize, pad)
+}
+
+// Encrypts input using Rijndael in OFB mode with custom block size (16, 24 or 32)
+func (ofb) EncryptWithBlockSize(input, key, iv []byte, blocksize int, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
 		return nil, InvalidDataError(lenInput)
 	}
-	block, err := stdaes.NewCipher(key)
+	block, err := NewRijndael(key, blocksize)
 	if err != nil {
 		return nil, err
 	}
-	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+	return ofbEncrypt(block, input, iv, pad)
+}
+
+func ofbEncrypt(block cipher.Block, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	err := IvSizeEquality(len(iv), block.BlockSize())
+	if err != nil {
 		return nil, err
 	}
 	if pad != nil {
@@ -32,17 +47,99 @@ func (ofb) Encrypt(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
 	return ct, nil
 }
 
-// Decrypts ciphertext using AES in OFB mode
-func (ofb) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+// Encrypts input using AES in OFB mode and appends the ciphertext to dst, without allocating
+// the output when dst has room for input and a block more,
+// the key schedule is still allocated on every call, use Cipher to avoid it
+func (ofb) EncryptTo(dst, input, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return ofbEncryptTo(block, dst, input, iv, pad)
+}
+
+func ofbEncryptTo(block cipher.Block, dst, input, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	if err := IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	ret, err := appendPadded(dst, input, pad, block.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	ofbXORInPlace(block, ret[len(dst):], iv)
+	return ret, nil
+}
+
+// Decrypts ciphertext using AES in OFB mode and appends the plaintext to dst, without allocating
+// the output when dst has room for ciphertext,
+// the key schedule is still allocated on every call, use Cipher to avoid it
+func (ofb) DecryptTo(dst, ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	if lenCt := len(ciphertext); lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return ofbDecryptTo(block, dst, ciphertext, iv, pad)
+}
+
+func ofbDecryptTo(block cipher.Block, dst, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
 	lenCt := len(ciphertext)
 	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	if err := IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	ret := append(dst, ciphertext...)
+	ofbXORInPlace(block, ret[len(dst):], iv)
+	return unpadAppended(ret, len(dst), pad, block.BlockSize())
+}
+
+// ofbXORInPlace XORs buf with the OFB keystream of iv.
+func ofbXORInPlace(block cipher.Block, buf, iv []byte) {
+	s := scratchPool.Get().(*[64]byte)
+	keystream := s[:block.BlockSize()]
+	copy(keystream, iv)
+	for len(buf) > 0 {
+		block.Encrypt(keystream, keystream)
+		buf = buf[subtle.XORBytes(buf, buf, keystream):]
+	}
+	scratchPool.Put(s)
+}
+
+// Decrypts ciphertext using AES in OFB mode
+func (ofb) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	return OFB.DecryptWithBlockSize(ciphertext, key, iv, stdaes.BlockSize, pad)
+}
+
+// Decrypts ciphertext using Rijndael in OFB mode with custom block size (16, 24 or 32)
+func (ofb) DecryptWithBlockSize(ciphertext, key, iv []byte, blocksize int, pad padding.Padding) ([]byte, error) {
+	if lenCt := len(ciphertext); lenCt == 0 {
 		return nil, InvalidDataError(lenCt)
 	}
-	block, err := stdaes.NewCipher(key)
+	block, err := NewRijndael(key, blocksize)
 	if err != nil {
 		return nil, err
 	}
-	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+	return ofbDecrypt(block, ciphertext, iv, pad)
+}
+
+func ofbDecrypt(block cipher.Block, ciphertext, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidDataError(lenCt)
+	}
+	err := IvSizeEquality(len(iv), block.BlockSize())
+	if err != nil {
 		return nil, err
 	}
 	mode := cipher.NewOFB(block, iv)
@@ -56,3 +153,60 @@ func (ofb) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, err
 	}
 	return pt, nil
 }
+
+// Returns a writer encrypting to w using AES in OFB mode, the padding is applied on Close which does not close w
+func (ofb) NewEncryptWriter(w io.Writer, key, iv []byte, pad padding.Padding) (io.WriteCloser, error) {
+	if err := validStreamPadding(pad); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	return newEncryptWriter(w, nil, cipher.NewOFB(block, iv), block.BlockSize(), pad), nil
+}
+
+// Returns a reader decrypting from r using AES in OFB mode, the padding is removed from the last block at EOF
+func (ofb) NewDecryptReader(r io.Reader, key, iv []byte, pad padding.Padding) (io.Reader, error) {
+	if err := validStreamPadding(pad); err != nil {
+		return nil, err
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = IvSizeEquality(len(iv), block.BlockSize()); err != nil {
+		return nil, err
+	}
+	return newDecryptReader(r, nil, cipher.NewOFB(block, iv), block.BlockSize(), pad), nil
+}
+
+// Encrypts input using AES in OFB mode under a random IV read from rand, crypto/rand.Reader when nil,
+// and returns the IV followed by the ciphertext
+func (ofb) EncryptAuto(rand io.Reader, input, key []byte, pad padding.Padding) ([]byte, error) {
+	if lenInput := len(input); lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	ret, err := appendRandom(make([]byte, 0, len(input)+2*bs), rand, bs)
+	if err != nil {
+		return nil, err
+	}
+	return ofbEncryptTo(block, ret, input, ret[:bs], pad)
+}
+
+// Decrypts ciphertext made of the IV followed by the ciphertext using AES in OFB mode, as returned by EncryptAuto
+func (ofb) DecryptAuto(ciphertext, key []byte, pad padding.Padding) ([]byte, error) {
+	iv, rest, err := splitPrefix(ciphertext, stdaes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	return OFB.DecryptTo(nil, rest, key, iv, pad)
+}
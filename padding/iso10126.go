@@ -37,12 +37,22 @@ func (iso10126) Unpad(b []byte, blocksize int) ([]byte, error) {
 	if lenB%blocksize != 0 {
 		return nil, InvalidDataError(lenB)
 	}
-	if lenB == 0 {
-		return nil, InvalidDataError(lenB)
-	}
 	padding := int(b[lenB-1])
-	if padding > lenB || padding > blocksize {
+	if padding == 0 || padding > blocksize {
 		return nil, InvalidDataError(lenB)
 	}
 	return b[:lenB-padding], nil
 }
+
+func (iso10126) padInPlace(b []byte, blocksize int) ([]byte, error) {
+	lenB := len(b)
+	padded, overhead, err := extend(b, blocksize)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(padded[lenB : lenB+overhead-1]); err != nil {
+		return nil, err
+	}
+	padded[lenB+overhead-1] = byte(overhead)
+	return padded, nil
+}
@@ -1,6 +1,13 @@
+// Package padding implements the block cipher paddings. The Unpad methods of
+// PKCS5, PKCS7, X923, ISO7816 and Bit check the whole last block in constant
+// time so that the time taken does not depend on the padding contents.
 package padding
 
-import "fmt"
+import (
+	"crypto/subtle"
+	"fmt"
+	"slices"
+)
 
 type Padding interface {
 	Pad(b []byte, blocksize int) ([]byte, error)
@@ -8,6 +15,11 @@ type Padding interface {
 	Unpad(b []byte, blocksize int) ([]byte, error)
 }
 
+// inPlacePadder is implemented by the paddings able to pad within the capacity of the data.
+type inPlacePadder interface {
+	padInPlace(b []byte, blocksize int) ([]byte, error)
+}
+
 type (
 	BlockSizeError   int
 	InvalidDataError int
@@ -43,3 +55,70 @@ func (i InvalidDataError) Error() string {
 }
 
 func OverheadSize(length, blocksize int) int { return blocksize - (length % blocksize) }
+
+// PadInPlace pads b with p within the capacity of b, so that no allocation
+// happens when it has room for a block more. The paddings defined outside
+// this package fall back to their Pad method.
+func PadInPlace(p Padding, b []byte, blocksize int) ([]byte, error) {
+	if ip, ok := p.(inPlacePadder); ok {
+		return ip.padInPlace(b, blocksize)
+	}
+	return p.Pad(b, blocksize)
+}
+
+// extend validates the data and block size, then grows b by its padding
+// overhead, within its capacity when possible.
+func extend(b []byte, blocksize int) ([]byte, int, error) {
+	lenB := len(b)
+	if lenB == 0 {
+		return nil, 0, InvalidDataError(lenB)
+	}
+	if blocksize <= 0 {
+		return nil, 0, BlockSizeError(blocksize)
+	}
+	overhead := OverheadSize(lenB, blocksize)
+	return slices.Grow(b, overhead)[:lenB+overhead], overhead, nil
+}
+
+// unpadResult returns b[:end] when good is 1 and an InvalidDataError when it
+// is 0. This is the only step branching on the validity of the padding, which
+// the error reveals anyway.
+func unpadResult(b []byte, end, good int) ([]byte, error) {
+	if good != 1 {
+		return nil, InvalidDataError(len(b))
+	}
+	return b[:end], nil
+}
+
+// unpadMarker removes a padding made of a 0x80 marker byte followed by zero
+// bytes within the last block.
+func unpadMarker(b []byte, blocksize int) ([]byte, error) {
+	lenB := len(b)
+	if lenB == 0 {
+		return nil, InvalidDataError(lenB)
+	}
+	if blocksize <= 0 {
+		return nil, BlockSizeError(blocksize)
+	}
+	if lenB%blocksize != 0 {
+		return nil, InvalidDataError(lenB)
+	}
+	end, good := markerEnd(b, blocksize)
+	return unpadResult(b, end, good)
+}
+
+// markerEnd returns where the marker padding of b starts and 1 when it is valid.
+func markerEnd(b []byte, blocksize int) (end, good int) {
+	lenB := len(b)
+	found, bad := 0, 0
+	for i := 1; i <= blocksize; i++ {
+		c := b[lenB-i]
+		searching := found ^ 1
+		marker := subtle.ConstantTimeByteEq(c, 0x80)
+		// Before the marker is found, every byte must be zero.
+		bad |= searching & ((marker | subtle.ConstantTimeByteEq(c, 0x00)) ^ 1)
+		end = subtle.ConstantTimeSelect(searching&marker, lenB-i, end)
+		found |= marker
+	}
+	return end, found & (bad ^ 1)
+}
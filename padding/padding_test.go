@@ -0,0 +1,138 @@
+package padding
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+const testBlockSize = 8
+
+type unpadTest struct {
+	name  string
+	in    string
+	want  string // unpadded data, when valid
+	valid bool
+}
+
+var unpadTests = map[Padding][]unpadTest{
+	PKCS7: {
+		{"not a multiple of the block size", "6162636465050505" + "0505", "", false},
+		{"one byte", "6162636465666701", "61626364656667", true},
+		{"three bytes", "6162636465030303", "6162636465", true},
+		{"full block", "6162636465666768" + "0808080808080808", "6162636465666768", true},
+		{"wrong pad byte", "6162636465030403", "", false},
+		{"zero length", "", "", false},
+		{"length greater than the block size", "0909090909090909", "", false},
+		{"all-zero block", "0000000000000000", "", false},
+	},
+	X923: {
+		{"one byte", "6162636465666701", "61626364656667", true},
+		{"three bytes", "6162636465000003", "6162636465", true},
+		{"full block", "0000000000000008", "", true},
+		{"wrong pad byte", "6162636465010003", "", false},
+		{"zero length", "", "", false},
+		{"length greater than the block size", "0000000000000009", "", false},
+		{"all-zero block", "0000000000000000", "", false},
+	},
+	ISO7816: {
+		{"one byte", "6162636465666780", "61626364656667", true},
+		{"three bytes", "6162636465800000", "6162636465", true},
+		{"full block", "8000000000000000", "", true},
+		{"wrong pad byte", "6162636465800100", "", false},
+		{"marker only in the previous block", "6162636465666780" + "0000000000000000", "", false},
+		{"zero length", "", "", false},
+		{"all-zero block", "0000000000000000", "", false},
+	},
+	ISO10126: {
+		{"one byte", "6162636465666701", "61626364656667", true},
+		{"three bytes", "61626364659a7f03", "6162636465", true},
+		{"full block", "1122334455667708", "", true},
+		{"zero length", "", "", false},
+		{"length greater than the block size", "0000000000000009", "", false},
+		{"all-zero block", "0000000000000000", "", false},
+	},
+	Zero: {
+		{"trailing zeros", "6162636465000000", "6162636465", true},
+		{"no padding", "6162636465666768", "6162636465666768", true},
+		{"all-zero block", "0000000000000000", "", true},
+		{"zero length", "", "", false},
+		{"not a multiple of the block size", "616263", "", false},
+	},
+}
+
+func init() {
+	unpadTests[PKCS5] = unpadTests[PKCS7]
+	unpadTests[Bit] = unpadTests[ISO7816]
+}
+
+func TestUnpad(t *testing.T) {
+	for p, tests := range unpadTests {
+		for _, tt := range tests {
+			in, _ := hex.DecodeString(tt.in)
+			got, err := p.Unpad(in, testBlockSize)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("%s %s: accepted %x as %x", p, tt.name, in, got)
+				}
+				continue
+			}
+			want, _ := hex.DecodeString(tt.want)
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("%s %s: got %x, %v, want %x", p, tt.name, got, err, want)
+			}
+		}
+		if _, err := p.Unpad(make([]byte, testBlockSize), 0); err != BlockSizeError(0) {
+			t.Errorf("%s: zero block size got %v", p, err)
+		}
+	}
+}
+
+func TestPadRoundTrip(t *testing.T) {
+	for p := range unpadTests {
+		if p == Zero {
+			continue
+		}
+		for n := 1; n <= 3*testBlockSize; n++ {
+			data := bytes.Repeat([]byte{0xa5}, n)
+			padded, err := p.Pad(bytes.Clone(data), testBlockSize)
+			if err != nil {
+				t.Fatalf("%s %d: %v", p, n, err)
+			}
+			if len(padded)%testBlockSize != 0 || len(padded) <= n || len(padded) > n+testBlockSize {
+				t.Errorf("%s %d: padded to %d bytes", p, n, len(padded))
+			}
+			inPlace, err := PadInPlace(p, append(make([]byte, 0, n+testBlockSize), data...), testBlockSize)
+			if err != nil || len(inPlace) != len(padded) || (p != ISO10126 && !bytes.Equal(inPlace, padded)) {
+				t.Errorf("%s %d: in place got %x, %v, want %x", p, n, inPlace, err, padded)
+			}
+			got, err := p.Unpad(padded, testBlockSize)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("%s %d: unpad got %x, %v", p, n, got, err)
+			}
+		}
+		if _, err := p.Pad(nil, testBlockSize); err != InvalidDataError(0) {
+			t.Errorf("%s: empty data got %v", p, err)
+		}
+	}
+}
+
+// TestPadCapacity checks that Pad leaves the spare capacity of its input
+// untouched, unlike PadInPlace.
+func TestPadCapacity(t *testing.T) {
+	for _, p := range []Padding{Bit, ISO10126, ISO7816, PKCS5, PKCS7, X923, Zero} {
+		for n := 1; n <= 2*testBlockSize; n++ {
+			b := bytes.Repeat([]byte{0xa5}, n+testBlockSize)[:n]
+			padded, err := p.Pad(b, testBlockSize)
+			if err != nil {
+				t.Fatalf("%s %d: %v", p, n, err)
+			}
+			if !bytes.Equal(b[:cap(b)], bytes.Repeat([]byte{0xa5}, n+testBlockSize)) {
+				t.Errorf("%s %d: the spare capacity was modified: %x", p, n, b[:cap(b)])
+			}
+			if len(padded) > 0 && &padded[0] == &b[0] {
+				t.Errorf("%s %d: the padded data aliases the input", p, n)
+			}
+		}
+	}
+}
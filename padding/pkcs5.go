@@ -17,3 +17,7 @@ func (pkcs5) Pad(b []byte, blocksize int) ([]byte, error) {
 func (pkcs5) Unpad(b []byte, blocksize int) ([]byte, error) {
 	return PKCS7.Unpad(b, blocksize)
 }
+
+func (pkcs5) padInPlace(b []byte, blocksize int) ([]byte, error) {
+	return PKCS7.padInPlace(b, blocksize)
+}
@@ -1,6 +1,9 @@
 package padding
 
-import "bytes"
+import (
+	"bytes"
+	"crypto/subtle"
+)
 
 func (pkcs7) String() string {
 	return "PKCS7Padding"
@@ -38,15 +41,32 @@ func (pkcs7) Unpad(b []byte, blocksize int) ([]byte, error) {
 	if lenB%blocksize != 0 {
 		return nil, InvalidDataError(lenB)
 	}
+	end, good := pkcs7End(b, blocksize)
+	return unpadResult(b, end, good)
+}
+
+// pkcs7End returns where the padding of b, made of bytes all equal to the last one, starts and 1 when it is valid.
+func pkcs7End(b []byte, blocksize int) (end, good int) {
+	lenB := len(b)
+	window := min(blocksize, 255)
 	c := b[lenB-1]
 	n := int(c)
-	if n == 0 || n > lenB {
-		return nil, InvalidDataError(lenB)
+	good = subtle.ConstantTimeLessOrEq(1, n) & subtle.ConstantTimeLessOrEq(n, window)
+	for i := 1; i <= window; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, n)
+		good &= subtle.ConstantTimeByteEq(b[lenB-i], c) | (inPadding ^ 1)
 	}
-	for i := 0; i < n; i++ {
-		if b[lenB-n+i] != c {
-			return nil, InvalidDataError(lenB)
-		}
+	return lenB - subtle.ConstantTimeSelect(good, n, 0), good
+}
+
+func (pkcs7) padInPlace(b []byte, blocksize int) ([]byte, error) {
+	lenB := len(b)
+	padded, overhead, err := extend(b, blocksize)
+	if err != nil {
+		return nil, err
 	}
-	return b[:lenB-n], nil
+	for i := lenB; i < len(padded); i++ {
+		padded[i] = byte(overhead)
+	}
+	return padded, nil
 }
@@ -0,0 +1,90 @@
+package padding
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type (
+	RegisteredError string
+	UnknownError    string
+)
+
+func (r RegisteredError) Error() string {
+	return fmt.Sprintf("padding: name %q is already registered", string(r))
+}
+
+func (u UnknownError) Error() string {
+	return fmt.Sprintf("padding: unknown padding %q", string(u))
+}
+
+var registry = struct {
+	sync.RWMutex
+	names map[string]Padding
+}{names: map[string]Padding{}}
+
+func init() {
+	builtins := []struct {
+		p       Padding
+		aliases []string
+	}{
+		{Bit, []string{"bit"}},
+		{ISO10126, []string{"iso10126", "iso 10126"}},
+		{ISO7816, []string{"iso7816", "iso7816-4", "iso/iec 7816-4"}},
+		{PKCS5, []string{"pkcs5", "pkcs#5"}},
+		{PKCS7, []string{"pkcs7", "pkcs#7"}},
+		{X923, []string{"x923", "ansix923", "ansi x9.23"}},
+		{Zero, []string{"zero"}},
+	}
+	for _, b := range builtins {
+		if err := Register(b.p, b.aliases...); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register makes p available to Lookup under its String value and the given
+// aliases, all compared case-insensitively. It fails without registering any
+// name when one of them is already registered or given twice, "NoPadding" and
+// "none" being reserved for no padding.
+func Register(p Padding, aliases ...string) error {
+	names := append([]string{p.String()}, aliases...)
+	registry.Lock()
+	defer registry.Unlock()
+	keys := make(map[string]bool, len(names))
+	for _, name := range names {
+		key := normalizeName(name)
+		if _, ok := registry.names[key]; ok || keys[key] || key == "nopadding" || key == "none" {
+			return RegisteredError(name)
+		}
+		keys[key] = true
+	}
+	for key := range keys {
+		registry.names[key] = p
+	}
+	return nil
+}
+
+// Lookup returns the padding registered under name, compared case-insensitively
+// and with or without a "Padding" suffix, such as "PKCS7Padding", "pkcs5" or
+// "ansix923". "NoPadding" and "none" return a nil Padding, which means no padding.
+func Lookup(name string) (Padding, error) {
+	lower := normalizeName(name)
+	if lower == "nopadding" || lower == "none" {
+		return nil, nil
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	if p, ok := registry.names[lower]; ok {
+		return p, nil
+	}
+	if p, ok := registry.names[lower+"padding"]; ok {
+		return p, nil
+	}
+	return nil, UnknownError(name)
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
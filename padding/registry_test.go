@@ -0,0 +1,107 @@
+package padding
+
+import (
+	"errors"
+	"testing"
+)
+
+// namedPadding is a PKCS#7 padding registered under another name.
+type namedPadding struct {
+	pkcs7
+	name string
+}
+
+func (n namedPadding) String() string { return n.name }
+
+func TestLookupAliases(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		want Padding
+	}{
+		{"BitPadding", Bit},
+		{"bit", Bit},
+		{"BIT", Bit},
+		{"ISO10126Padding", ISO10126},
+		{"iso 10126", ISO10126},
+		{"ISO7816Padding", ISO7816},
+		{"ISO7816-4", ISO7816},
+		{"iso/iec 7816-4", ISO7816},
+		{"PKCS5Padding", PKCS5},
+		{"pkcs5", PKCS5},
+		{"PKCS#5", PKCS5},
+		{"pkcs7padding", PKCS7},
+		{"Pkcs7", PKCS7},
+		{"pkcs#7", PKCS7},
+		{"X923Padding", X923},
+		{"ANSIX923", X923},
+		{"ANSI X9.23", X923},
+		{" ZeroPadding ", Zero},
+		{"zero", Zero},
+		{"NoPadding", nil},
+		{"nopadding", nil},
+		{"NONE", nil},
+	} {
+		got, err := Lookup(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	for _, name := range []string{"", "padding", "pkcs", "pkcs7 padding", "x9.23", "foo"} {
+		_, err := Lookup(name)
+		var target UnknownError
+		if !errors.As(err, &target) || string(target) != name {
+			t.Errorf("%q: got %v, want UnknownError(%q)", name, err, name)
+		}
+	}
+}
+
+// registered is registered once per test binary, so that the tests survive -count.
+var (
+	registered    = namedPadding{name: "RegisterTestPadding"}
+	registeredErr = Register(registered, "register-test", "Register Test Alias")
+)
+
+func TestRegister(t *testing.T) {
+	if registeredErr != nil {
+		t.Fatal(registeredErr)
+	}
+	p := registered
+	for _, name := range []string{"RegisterTestPadding", "registertestpadding", "REGISTERTEST", "register-test", "REGISTER-TEST", "register test alias"} {
+		got, err := Lookup(name)
+		if err != nil || got != p {
+			t.Errorf("%q: got %v, %v, want %v", name, got, err, p)
+		}
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	for _, tt := range []struct {
+		p       Padding
+		aliases []string
+		dup     string
+	}{
+		{PKCS7, nil, "PKCS7Padding"},
+		{namedPadding{name: "pkcs7padding"}, nil, "pkcs7padding"},
+		{namedPadding{name: "DuplicateTestPadding"}, []string{"PKCS#5"}, "PKCS#5"},
+		{namedPadding{name: "DuplicateTestPadding"}, []string{"ansix923"}, "ansix923"},
+		{namedPadding{name: "NoPadding"}, nil, "NoPadding"},
+		{namedPadding{name: "DuplicateTestPadding"}, []string{"None"}, "None"},
+		{namedPadding{name: "DuplicateTestPadding"}, []string{"dup", " DUP "}, " DUP "},
+	} {
+		err := Register(tt.p, tt.aliases...)
+		var target RegisteredError
+		if !errors.As(err, &target) || string(target) != tt.dup {
+			t.Errorf("Register(%q, %q): got %v, want RegisteredError(%q)", tt.p, tt.aliases, err, tt.dup)
+		}
+	}
+	// A failed registration registers none of its names.
+	if _, err := Lookup("DuplicateTestPadding"); err == nil {
+		t.Error("DuplicateTestPadding was registered by a failed Register")
+	}
+	if _, err := Lookup("dup"); err == nil {
+		t.Error("dup was registered by a failed Register")
+	}
+}
@@ -0,0 +1,142 @@
+package padding
+
+import (
+	"crypto/rand"
+	"math"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+// dudectThreshold is the Welch t statistic above which the timings of the
+// two classes are considered distinguishable, as in dudect. The smoke check
+// run by default only catches gross leaks, such as an early return.
+const (
+	dudectThreshold      = 10
+	dudectSmokeThreshold = 100
+)
+
+// timingSink keeps the measured calls from being optimized away.
+var timingSink int
+
+// welch returns the Welch t statistic of two samples.
+func welch(a, b []float64) float64 {
+	mean := func(x []float64) (m float64) {
+		for _, v := range x {
+			m += v
+		}
+		return m / float64(len(x))
+	}
+	variance := func(x []float64, m float64) (v float64) {
+		for _, e := range x {
+			v += (e - m) * (e - m)
+		}
+		return v / float64(len(x)-1)
+	}
+	ma, mb := mean(a), mean(b)
+	return (ma - mb) / math.Sqrt(variance(a, ma)/float64(len(a))+variance(b, mb)/float64(len(b)))
+}
+
+// crop drops the measurements above the given percentile, which are mostly
+// interrupts and scheduling noise.
+func crop(x []float64, percentile float64) []float64 {
+	sorted := slices.Clone(x)
+	slices.Sort(sorted)
+	limit := sorted[int(percentile*float64(len(sorted)-1))]
+	return slices.DeleteFunc(x, func(v float64) bool { return v > limit })
+}
+
+// timingInputs returns measurements inputs of the same length for p, each in
+// class 0 or 1 at random. Class 0 repeats one fixed input and class 1 draws a
+// new one every time, all valid or all invalid as asked.
+func timingInputs(t *testing.T, p Padding, blocksize, measurements int, valid bool) (inputs [][]byte, classes []byte) {
+	draw := func() []byte {
+		for {
+			var b []byte
+			if valid {
+				n := make([]byte, 1)
+				rand.Read(n)
+				data := make([]byte, 2*blocksize-1-int(n[0])%blocksize)
+				rand.Read(data)
+				padded, err := p.Pad(data, blocksize)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b = padded
+			} else {
+				b = make([]byte, 2*blocksize)
+				rand.Read(b)
+			}
+			if _, err := p.Unpad(slices.Clone(b), blocksize); (err == nil) == valid {
+				return b
+			}
+		}
+	}
+	fixed := draw()
+	classes = make([]byte, measurements)
+	rand.Read(classes)
+	inputs = make([][]byte, measurements)
+	for i := range inputs {
+		classes[i] &= 1
+		if classes[i] == 0 {
+			inputs[i] = slices.Clone(fixed)
+		} else {
+			inputs[i] = draw()
+		}
+	}
+	return inputs, classes
+}
+
+// unpadTiming returns the Welch t statistic of the time taken by p.Unpad on the
+// two classes of timingInputs, interleaved at random so that drifts in the
+// machine state affect both alike.
+func unpadTiming(t *testing.T, p Padding, measurements int, valid bool) float64 {
+	const (
+		blocksize = 16
+		batch     = 64
+	)
+	inputs, classes := timingInputs(t, p, blocksize, measurements, valid)
+	var a, b []float64
+	for i, in := range inputs {
+		start := time.Now()
+		for j := 0; j < batch; j++ {
+			out, err := p.Unpad(in, blocksize)
+			if err == nil {
+				timingSink += len(out)
+			}
+		}
+		d := float64(time.Since(start))
+		if classes[i] == 0 {
+			a = append(a, d)
+		} else {
+			b = append(b, d)
+		}
+	}
+	return welch(crop(a, 0.9), crop(b, 0.9))
+}
+
+// TestUnpadTiming is a dudect-style leakage test of the Unpad methods checking
+// the padding in constant time. A fixed input is compared with random inputs
+// of the same validity: valid inputs with random data and padding lengths, then
+// invalid inputs failing the check at random places. Unpad branches only on the
+// validity, which its error reveals anyway.
+//
+// As a wall-clock statistical test it runs in full only with AES_TIMING=1, and
+// is otherwise reduced to a quick smoke check against gross leaks.
+func TestUnpadTiming(t *testing.T) {
+	measurements, threshold := 2000, float64(dudectSmokeThreshold)
+	if os.Getenv("AES_TIMING") == "1" {
+		measurements, threshold = 20000, dudectThreshold
+	}
+	for _, p := range []Padding{PKCS7, X923, ISO7816, Bit} {
+		for _, valid := range []bool{true, false} {
+			tv := unpadTiming(t, p, measurements, valid)
+			if math.Abs(tv) > threshold {
+				t.Errorf("%s: timings of fixed and random inputs (valid %v) differ, t = %.2f", p, valid, tv)
+			} else {
+				t.Logf("%s: valid %v, t = %.2f", p, valid, tv)
+			}
+		}
+	}
+}
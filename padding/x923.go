@@ -1,5 +1,7 @@
 package padding
 
+import "crypto/subtle"
+
 func (x923) String() string {
 	return "X923Padding"
 }
@@ -35,14 +37,30 @@ func (x923) Unpad(b []byte, blocksize int) ([]byte, error) {
 	if lenB%blocksize != 0 {
 		return nil, InvalidDataError(lenB)
 	}
-	if lenB == 0 {
-		return nil, InvalidDataError(lenB)
+	end, good := x923End(b, blocksize)
+	return unpadResult(b, end, good)
+}
+
+// x923End returns where the padding of b, made of zero bytes ended by its length, starts and 1 when it is valid.
+func x923End(b []byte, blocksize int) (end, good int) {
+	lenB := len(b)
+	window := min(blocksize, 255)
+	n := int(b[lenB-1])
+	good = subtle.ConstantTimeLessOrEq(1, n) & subtle.ConstantTimeLessOrEq(n, window)
+	for i := 2; i <= window; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, n)
+		good &= subtle.ConstantTimeByteEq(b[lenB-i], 0) | (inPadding ^ 1)
 	}
-	padding := int(b[lenB-1])
-	for i := lenB - padding; i < lenB-1; i++ {
-		if b[i] != 0 {
-			return nil, InvalidDataError(lenB)
-		}
+	return lenB - subtle.ConstantTimeSelect(good, n, 0), good
+}
+
+func (x923) padInPlace(b []byte, blocksize int) ([]byte, error) {
+	lenB := len(b)
+	padded, overhead, err := extend(b, blocksize)
+	if err != nil {
+		return nil, err
 	}
-	return b[:lenB-padding], nil
+	clear(padded[lenB : lenB+overhead-1])
+	padded[lenB+overhead-1] = byte(overhead)
+	return padded, nil
 }
@@ -15,8 +15,9 @@ func (zero) Pad(b []byte, blocksize int) ([]byte, error) {
 		return nil, BlockSizeError(blocksize)
 	}
 	overhead := OverheadSize(lenB, blocksize)
-	padtext := bytes.Repeat([]byte{byte(0)}, overhead)
-	return append(b, padtext...), nil
+	padded := make([]byte, lenB+overhead)
+	copy(padded, b)
+	return padded, nil
 }
 
 func (zero) Unpad(b []byte, blocksize int) ([]byte, error) {
@@ -34,3 +35,13 @@ func (zero) Unpad(b []byte, blocksize int) ([]byte, error) {
 		return r == 0
 	}), nil
 }
+
+func (zero) padInPlace(b []byte, blocksize int) ([]byte, error) {
+	lenB := len(b)
+	padded, _, err := extend(b, blocksize)
+	if err != nil {
+		return nil, err
+	}
+	clear(padded[lenB:])
+	return padded, nil
+}
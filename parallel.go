@@ -0,0 +1,33 @@
+package aes
+
+import (
+	"runtime"
+	"sync"
+)
+
+// parallelMinChunkSize is the smallest amount of data handed to a worker, below
+// which the goroutine overhead outweighs the gain.
+const parallelMinChunkSize int = 64 * 1024
+
+// parallelize splits length bytes into chunks of whole blocks and calls fn on
+// each of them from at most workers goroutines, GOMAXPROCS when workers <= 0.
+func parallelize(length, blocksize, workers int, fn func(start, end int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	chunk := max((length+workers-1)/workers, parallelMinChunkSize)
+	chunk = (chunk + blocksize - 1) / blocksize * blocksize
+	if chunk >= length {
+		fn(0, length)
+		return
+	}
+	var wg sync.WaitGroup
+	for start := 0; start < length; start += chunk {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, min(start+chunk, length))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,114 @@
+package aes
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/colduction/aes/padding"
+)
+
+func TestParallelize(t *testing.T) {
+	for _, length := range []int{16, 3 * 16, parallelMinChunkSize, 5*parallelMinChunkSize + 48} {
+		for _, workers := range []int{0, 1, 2, runtime.GOMAXPROCS(0), length/16 + 7} {
+			covered := make([]int32, length)
+			parallelize(length, 16, workers, func(start, end int) {
+				if start%16 != 0 {
+					t.Errorf("chunk starts at %d", start)
+				}
+				for i := start; i < end; i++ {
+					covered[i]++
+				}
+			})
+			for i, c := range covered {
+				if c != 1 {
+					t.Fatalf("length %d, %d workers: byte %d processed %d times", length, workers, i, c)
+				}
+			}
+		}
+	}
+}
+
+func TestParallelMatchesSerial(t *testing.T) {
+	key := bytes.Repeat([]byte{5}, 32)
+	iv := bytes.Repeat([]byte{0xff}, 16)
+	for _, n := range []int{1, 3 * 16, 1000, 1 << 20, 3<<20 + 5} {
+		pt := make([]byte, n)
+		for i := range pt {
+			pt[i] = byte(i * 13)
+		}
+		ecb, _ := ECB.Encrypt(pt, key, padding.PKCS7)
+		ctr, _ := CTR.Encrypt(pt, key, iv, nil)
+		cbc, _ := CBC.Encrypt(pt, key, iv, padding.PKCS7)
+		// The last count gives more workers than there are blocks.
+		for _, workers := range []int{1, 2, runtime.GOMAXPROCS(0), n/16 + 7} {
+			name := fmt.Sprintf("%d bytes, %d workers", n, workers)
+			if got, err := ECB.EncryptParallel(pt, key, workers, padding.PKCS7); err != nil || !bytes.Equal(got, ecb) {
+				t.Errorf("%s: ECB encrypt differs, %v", name, err)
+			}
+			if got, err := ECB.DecryptParallel(ecb, key, workers, padding.PKCS7); err != nil || !bytes.Equal(got, pt) {
+				t.Errorf("%s: ECB decrypt differs, %v", name, err)
+			}
+			if got, err := CTR.EncryptParallel(pt, key, iv, workers, nil); err != nil || !bytes.Equal(got, ctr) {
+				t.Errorf("%s: CTR encrypt differs, %v", name, err)
+			}
+			if got, err := CTR.DecryptParallel(ctr, key, iv, workers, nil); err != nil || !bytes.Equal(got, pt) {
+				t.Errorf("%s: CTR decrypt differs, %v", name, err)
+			}
+			if got, err := CBC.DecryptParallel(cbc, key, iv, workers, padding.PKCS7); err != nil || !bytes.Equal(got, pt) {
+				t.Errorf("%s: CBC decrypt differs, %v", name, err)
+			}
+		}
+	}
+}
+
+var parallelBenchSizes = []struct {
+	name string
+	size int
+}{
+	{"1MiB", 1 << 20},
+	{"64MiB", 64 << 20},
+	{"1GiB", 1 << 30},
+}
+
+func benchmarkParallel(b *testing.B, fn func(input []byte, workers int) ([]byte, error)) {
+	for _, s := range parallelBenchSizes {
+		for _, workers := range []int{1, 0} {
+			b.Run(fmt.Sprintf("%s/workers=%d", s.name, workers), func(b *testing.B) {
+				if s.size > 64<<20 && testing.Short() {
+					b.Skip("skipping 1GiB input in short mode")
+				}
+				input := make([]byte, s.size)
+				b.SetBytes(int64(s.size))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := fn(input, workers); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkECBParallel(b *testing.B) {
+	key := make([]byte, 16)
+	benchmarkParallel(b, func(input []byte, workers int) ([]byte, error) {
+		return ECB.EncryptParallel(input, key, workers, nil)
+	})
+}
+
+func BenchmarkCTRParallel(b *testing.B) {
+	key, iv := make([]byte, 16), make([]byte, 16)
+	benchmarkParallel(b, func(input []byte, workers int) ([]byte, error) {
+		return CTR.EncryptParallel(input, key, iv, workers, nil)
+	})
+}
+
+func BenchmarkCBCDecryptParallel(b *testing.B) {
+	key, iv := make([]byte, 16), make([]byte, 16)
+	benchmarkParallel(b, func(input []byte, workers int) ([]byte, error) {
+		return CBC.DecryptParallel(input, key, iv, workers, nil)
+	})
+}
@@ -0,0 +1,76 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/subtle"
+
+	"github.com/colduction/aes/padding"
+)
+
+// Encrypts input using AES in PCBC mode
+func (pcbc) Encrypt(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	if err = IvSizeEquality(len(iv), bs); err != nil {
+		return nil, err
+	}
+	if pad != nil {
+		if input, err = pad.Pad(input, bs); err != nil {
+			return nil, err
+		}
+		lenInput = len(input)
+	}
+	if lenInput%bs != 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	ct := make([]byte, lenInput)
+	prev := make([]byte, bs)
+	copy(prev, iv)
+	for s, e := 0, bs; s < lenInput; s, e = e, e+bs {
+		subtle.XORBytes(ct[s:e], input[s:e], prev)
+		block.Encrypt(ct[s:e], ct[s:e])
+		subtle.XORBytes(prev, input[s:e], ct[s:e])
+	}
+	return ct, nil
+}
+
+// Decrypts ciphertext using AES in PCBC mode
+func (pcbc) Decrypt(ciphertext, key, iv []byte, pad padding.Padding) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	if lenCt%bs != 0 {
+		return nil, InvalidDataError(lenCt)
+	}
+	if err = IvSizeEquality(len(iv), bs); err != nil {
+		return nil, err
+	}
+	pt := make([]byte, lenCt)
+	prev := make([]byte, bs)
+	copy(prev, iv)
+	for s, e := 0, bs; s < lenCt; s, e = e, e+bs {
+		block.Decrypt(pt[s:e], ciphertext[s:e])
+		subtle.XORBytes(pt[s:e], pt[s:e], prev)
+		subtle.XORBytes(prev, pt[s:e], ciphertext[s:e])
+	}
+	if pad != nil {
+		pt, err = pad.Unpad(pt, bs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pt, nil
+}
@@ -0,0 +1,79 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/colduction/aes/padding"
+)
+
+func TestPCBCRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	iv := bytes.Repeat([]byte{2}, 16)
+	for _, n := range []int{1, 15, 16, 17, 64, 100} {
+		msg := bytes.Repeat([]byte{'p'}, n)
+		ct, err := PCBC.Encrypt(msg, key, iv, padding.PKCS7)
+		if err != nil {
+			t.Fatalf("%d: %v", n, err)
+		}
+		pt, err := PCBC.Decrypt(ct, key, iv, padding.PKCS7)
+		if err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%d: got %x, %v", n, pt, err)
+		}
+	}
+	// The first block is plain CBC; later ones also fold in the plaintext.
+	msg := []byte("the quick brown fox jumps over the lazy dog")
+	ct, _ := PCBC.Encrypt(msg, key, iv, padding.PKCS7)
+	cbc, _ := CBC.Encrypt(msg, key, iv, padding.PKCS7)
+	if !bytes.Equal(ct[:16], cbc[:16]) || bytes.Equal(ct[16:32], cbc[16:32]) {
+		t.Errorf("got %x, CBC %x", ct, cbc)
+	}
+	if _, err := PCBC.Encrypt(nil, key, iv, padding.PKCS7); err == nil {
+		t.Error("empty input accepted")
+	}
+	if _, err := PCBC.Encrypt(msg, key, iv[:8], padding.PKCS7); err == nil {
+		t.Error("short IV accepted")
+	}
+}
+
+func TestPCBCErrorPropagation(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	iv := bytes.Repeat([]byte{2}, 16)
+	msg := bytes.Repeat([]byte("pcbcpcbcpcbcpcbc"), 6)
+	ct, err := PCBC.Encrypt(msg, key, iv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A ciphertext bit flip garbles its own block and every later one.
+	tampered := bytes.Clone(ct)
+	tampered[33] ^= 1
+	pt, err := PCBC.Decrypt(tampered, key, iv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pt[:32], msg[:32]) {
+		t.Errorf("blocks before the flip changed: %x", pt[:32])
+	}
+	for i := 32; i < len(msg); i += 16 {
+		if bytes.Equal(pt[i:i+16], msg[i:i+16]) {
+			t.Errorf("block %d not garbled", i/16)
+		}
+	}
+
+	// A plaintext bit flip changes its own ciphertext block and every later one.
+	changed := bytes.Clone(msg)
+	changed[17] ^= 1
+	ct2, err := PCBC.Encrypt(changed, key, iv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ct2[:16], ct[:16]) {
+		t.Error("block before the flip changed")
+	}
+	for i := 16; i < len(ct); i += 16 {
+		if bytes.Equal(ct2[i:i+16], ct[i:i+16]) {
+			t.Errorf("ciphertext block %d unchanged", i/16)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package aes
+
+import "encoding/binary"
+
+// gfElement is an element of GF(2^128) in the bit-reflected representation used by GHASH.
+type gfElement struct {
+	hi, lo uint64
+}
+
+func gfLoad(b []byte) gfElement {
+	return gfElement{binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:16])}
+}
+
+func (e gfElement) store(b []byte) {
+	binary.BigEndian.PutUint64(b[:8], e.hi)
+	binary.BigEndian.PutUint64(b[8:16], e.lo)
+}
+
+// mulX multiplies e by x in the GHASH field.
+func (e gfElement) mulX() gfElement {
+	lsb := e.lo & 1
+	return gfElement{e.hi>>1 ^ (0xe100000000000000 & -lsb), e.lo>>1 | e.hi<<63}
+}
+
+// mul multiplies e by y in the GHASH field in constant time.
+func (e gfElement) mul(y gfElement) gfElement {
+	var z gfElement
+	v := y
+	for i := 0; i < 128; i++ {
+		var bit uint64
+		if i < 64 {
+			bit = e.hi >> (63 - i) & 1
+		} else {
+			bit = e.lo >> (127 - i) & 1
+		}
+		z.hi ^= v.hi & -bit
+		z.lo ^= v.lo & -bit
+		v = v.mulX()
+	}
+	return z
+}
+
+// byteReverse16 reverses the order of the 16 bytes of src into dst.
+func byteReverse16(dst, src []byte) {
+	var t [16]byte
+	for i := 0; i < 16; i++ {
+		t[i] = src[15-i]
+	}
+	copy(dst, t[:])
+}
+
+// polyval implements the POLYVAL universal hash of RFC 8452 through its
+// GHASH equivalence: POLYVAL(H, X) = ByteReverse(GHASH(mulX_GHASH(ByteReverse(H)), ByteReverse(X))).
+type polyval struct {
+	h, s gfElement
+}
+
+func newPolyval(key []byte) *polyval {
+	var k [16]byte
+	byteReverse16(k[:], key)
+	return &polyval{h: gfLoad(k[:]).mulX()}
+}
+
+// update absorbs b, zero padding its last partial block.
+func (p *polyval) update(b []byte) {
+	var block [16]byte
+	for len(b) > 0 {
+		block = [16]byte{}
+		n := copy(block[:], b)
+		b = b[n:]
+		byteReverse16(block[:], block[:])
+		x := gfLoad(block[:])
+		p.s = gfElement{p.s.hi ^ x.hi, p.s.lo ^ x.lo}.mul(p.h)
+	}
+}
+
+func (p *polyval) sum(out []byte) {
+	p.s.store(out)
+	byteReverse16(out, out)
+}
@@ -0,0 +1,18 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+// RFC 8452 appendix A.
+func TestPolyval(t *testing.T) {
+	p := newPolyval(fromHex(t, "25629347589242761d31f826ba4b757b"))
+	p.update(fromHex(t, "4f4f95668c83dfb6401762bb2d01a262"))
+	p.update(fromHex(t, "d1a24ddd2721d006bbe45f20d3c9f362"))
+	var got [16]byte
+	p.sum(got[:])
+	if want := fromHex(t, "f7a3b47b846119fae5b7866cf5e5b77e"); !bytes.Equal(got[:], want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
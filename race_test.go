@@ -0,0 +1,7 @@
+//go:build race
+
+package aes
+
+// raceEnabled is set when testing with -race, under which sync.Pool drops
+// items at random and allocation counts are meaningless.
+const raceEnabled = true
@@ -0,0 +1,247 @@
+package aes
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/colduction/aes/padding"
+)
+
+// Mode is a confidentiality mode resolved by Lookup, bound to a key size and a padding.
+type Mode interface {
+	// Name returns the canonical name of the mode, which Lookup resolves back to it.
+	Name() string
+	// KeySize returns the key size in bytes, or 0 when every AES key size is accepted.
+	KeySize() int
+	// IvSize returns the IV or nonce size in bytes, 0 when the mode takes none.
+	IvSize() int
+	Encrypt(input, key, iv []byte) ([]byte, error)
+	Decrypt(ciphertext, key, iv []byte) ([]byte, error)
+}
+
+// AEAD is a Mode providing authenticated encryption with additional data. Its
+// Encrypt and Decrypt methods authenticate no additional data.
+type AEAD interface {
+	Mode
+	// Overhead returns the size of the tag appended to the ciphertext.
+	Overhead() int
+	Seal(input, key, nonce, additionalData []byte) ([]byte, error)
+	Open(ciphertext, key, nonce, additionalData []byte) ([]byte, error)
+}
+
+type AlgorithmError string
+
+func (a AlgorithmError) Error() string {
+	return fmt.Sprintf("aes: unknown algorithm %q", string(a))
+}
+
+type (
+	modeFunc func(input, key, iv []byte, pad padding.Padding) ([]byte, error)
+	aeadFunc func(input, key, nonce, additionalData []byte, pad padding.Padding) ([]byte, error)
+)
+
+// modeSpec describes a mode of the registry, defaultPad being applied when the name has no padding part.
+type modeSpec struct {
+	name       string
+	ivSize     int
+	keySizes   []int
+	defaultPad padding.Padding
+	encrypt    modeFunc
+	decrypt    modeFunc
+	seal       aeadFunc
+	open       aeadFunc
+	overhead   int
+}
+
+var aesKeySizes = []int{16, 24, 32}
+
+// modes maps the mode part of the OpenSSL and JCA names to their spec.
+var modes = map[string]*modeSpec{}
+
+func registerMode(spec *modeSpec, aliases ...string) {
+	modes[spec.name] = spec
+	for _, alias := range aliases {
+		modes[alias] = spec
+	}
+}
+
+func init() {
+	registerMode(&modeSpec{name: "cbc", ivSize: 16, keySizes: aesKeySizes, defaultPad: padding.PKCS7, encrypt: CBC.Encrypt, decrypt: CBC.Decrypt})
+	registerMode(&modeSpec{name: "cfb", ivSize: 16, keySizes: aesKeySizes, encrypt: CFB.Encrypt, decrypt: CFB.Decrypt}, "cfb128")
+	registerMode(&modeSpec{name: "cfb1", ivSize: 16, keySizes: aesKeySizes, encrypt: cfbSegmentFunc(CFB.EncryptWithSegmentSize, 1), decrypt: cfbSegmentFunc(CFB.DecryptWithSegmentSize, 1)})
+	registerMode(&modeSpec{name: "cfb8", ivSize: 16, keySizes: aesKeySizes, encrypt: cfbSegmentFunc(CFB.EncryptWithSegmentSize, 8), decrypt: cfbSegmentFunc(CFB.DecryptWithSegmentSize, 8)})
+	registerMode(&modeSpec{name: "cfb64", ivSize: 16, keySizes: aesKeySizes, encrypt: cfbSegmentFunc(CFB.EncryptWithSegmentSize, 64), decrypt: cfbSegmentFunc(CFB.DecryptWithSegmentSize, 64)})
+	registerMode(&modeSpec{name: "ctr", ivSize: 16, keySizes: aesKeySizes, encrypt: CTR.Encrypt, decrypt: CTR.Decrypt})
+	registerMode(&modeSpec{name: "ecb", keySizes: aesKeySizes, defaultPad: padding.PKCS7, encrypt: ecbFunc(ECB.Encrypt), decrypt: ecbFunc(ECB.Decrypt)})
+	registerMode(&modeSpec{name: "ige", ivSize: 32, keySizes: aesKeySizes, defaultPad: padding.PKCS7, encrypt: IGE.Encrypt, decrypt: IGE.Decrypt})
+	registerMode(&modeSpec{name: "ofb", ivSize: 16, keySizes: aesKeySizes, encrypt: OFB.Encrypt, decrypt: OFB.Decrypt})
+	registerMode(&modeSpec{name: "pcbc", ivSize: 16, keySizes: aesKeySizes, defaultPad: padding.PKCS7, encrypt: PCBC.Encrypt, decrypt: PCBC.Decrypt})
+	registerMode(&modeSpec{name: "ccm", ivSize: 12, keySizes: aesKeySizes, overhead: 16, seal: aeadFuncOf(CCM.Encrypt), open: aeadFuncOf(CCM.Decrypt)})
+	registerMode(&modeSpec{name: "eax", ivSize: 16, keySizes: aesKeySizes, overhead: 16, seal: aeadFuncOf(EAX.Encrypt), open: aeadFuncOf(EAX.Decrypt)})
+	registerMode(&modeSpec{name: "gcm", ivSize: 12, keySizes: aesKeySizes, overhead: 16, seal: aeadFuncOf(GCM.Encrypt), open: aeadFuncOf(GCM.Decrypt)})
+	registerMode(&modeSpec{name: "gcm-siv", ivSize: 12, keySizes: []int{16, 32}, overhead: 16, seal: aeadFuncOf(GCMSIV.Encrypt), open: aeadFuncOf(GCMSIV.Decrypt)}, "gcmsiv")
+	registerMode(&modeSpec{name: "ocb", ivSize: 12, keySizes: aesKeySizes, overhead: 16, seal: aeadFuncOf(OCB.Encrypt), open: aeadFuncOf(OCB.Decrypt)})
+}
+
+func cfbSegmentFunc(fn func(input, key, iv []byte, segmentSize int, pad padding.Padding) ([]byte, error), segmentSize int) modeFunc {
+	return func(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
+		return fn(input, key, iv, segmentSize, pad)
+	}
+}
+
+func ecbFunc(fn func(input, key []byte, pad padding.Padding) ([]byte, error)) modeFunc {
+	return func(input, key, iv []byte, pad padding.Padding) ([]byte, error) {
+		if lenIv := len(iv); lenIv != 0 {
+			return nil, IvSizeError(lenIv)
+		}
+		return fn(input, key, pad)
+	}
+}
+
+func aeadFuncOf(fn func(input, key, nonce, additionalData []byte, pad padding.Padding, dst ...byte) ([]byte, error)) aeadFunc {
+	return func(input, key, nonce, additionalData []byte, pad padding.Padding) ([]byte, error) {
+		return fn(input, key, nonce, additionalData, pad)
+	}
+}
+
+// LookupPadding resolves a padding by name through padding.Lookup.
+func LookupPadding(name string) (padding.Padding, error) {
+	return padding.Lookup(name)
+}
+
+// Lookup resolves an algorithm name to its Mode, which also implements AEAD for
+// the authenticated modes. It accepts, case-insensitively, the OpenSSL names
+// such as "aes-256-gcm" or "aes-128-cbc" with an optional "/padding" suffix, and
+// the JCA names such as "AES/CBC/PKCS5Padding" or "AES_256/GCM/NoPadding".
+// Without a key size, every AES key size is accepted. The CBC, ECB, IGE and PCBC
+// modes default to PKCS#7 padding and the other modes to none.
+func Lookup(name string) (Mode, error) {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	var (
+		modeName, padName string
+		hasPad            bool
+		bits              string
+	)
+	if strings.HasPrefix(lower, "aes/") || strings.HasPrefix(lower, "aes_") {
+		parts := strings.Split(lower, "/")
+		if len(parts) != 3 {
+			return nil, AlgorithmError(name)
+		}
+		bits, _ = strings.CutPrefix(parts[0], "aes_")
+		if bits == "aes" {
+			bits = ""
+		}
+		modeName, padName, hasPad = parts[1], parts[2], true
+	} else {
+		base, suffix, ok := strings.Cut(lower, "/")
+		rest, found := strings.CutPrefix(base, "aes-")
+		if !found {
+			return nil, AlgorithmError(name)
+		}
+		modeName, padName, hasPad = rest, suffix, ok
+		if size, mode, ok := strings.Cut(rest, "-"); ok {
+			if _, err := strconv.Atoi(size); err == nil {
+				bits, modeName = size, mode
+			}
+		}
+	}
+	spec, ok := modes[modeName]
+	if !ok {
+		return nil, AlgorithmError(name)
+	}
+	keySize := 0
+	if bits != "" {
+		n, err := strconv.Atoi(bits)
+		if err != nil || n%8 != 0 || !slices.Contains(spec.keySizes, n/8) {
+			return nil, AlgorithmError(name)
+		}
+		keySize = n / 8
+	}
+	pad := spec.defaultPad
+	if hasPad {
+		var err error
+		if pad, err = LookupPadding(padName); err != nil {
+			return nil, AlgorithmError(name)
+		}
+	}
+	m := registeredMode{spec: spec, keySize: keySize, pad: pad}
+	if spec.seal != nil {
+		return registeredAEAD{m}, nil
+	}
+	return m, nil
+}
+
+type registeredMode struct {
+	spec    *modeSpec
+	keySize int
+	pad     padding.Padding
+}
+
+func (m registeredMode) Name() string {
+	name := "aes-" + m.spec.name
+	if m.keySize != 0 {
+		name = fmt.Sprintf("aes-%d-%s", 8*m.keySize, m.spec.name)
+	}
+	if m.pad == nil {
+		return name + "/NoPadding"
+	}
+	return name + "/" + m.pad.String()
+}
+
+func (m registeredMode) KeySize() int { return m.keySize }
+
+func (m registeredMode) registered() registeredMode { return m }
+
+func (m registeredMode) IvSize() int { return m.spec.ivSize }
+
+func (m registeredMode) validKey(key []byte) error {
+	lenKey := len(key)
+	if m.keySize != 0 && lenKey != m.keySize || !slices.Contains(m.spec.keySizes, lenKey) {
+		return KeySizeError(lenKey)
+	}
+	return nil
+}
+
+func (m registeredMode) Encrypt(input, key, iv []byte) ([]byte, error) {
+	if err := m.validKey(key); err != nil {
+		return nil, err
+	}
+	return m.spec.encrypt(input, key, iv, m.pad)
+}
+
+func (m registeredMode) Decrypt(ciphertext, key, iv []byte) ([]byte, error) {
+	if err := m.validKey(key); err != nil {
+		return nil, err
+	}
+	return m.spec.decrypt(ciphertext, key, iv, m.pad)
+}
+
+type registeredAEAD struct {
+	registeredMode
+}
+
+func (a registeredAEAD) Overhead() int { return a.spec.overhead }
+
+func (a registeredAEAD) Encrypt(input, key, nonce []byte) ([]byte, error) {
+	return a.Seal(input, key, nonce, nil)
+}
+
+func (a registeredAEAD) Decrypt(ciphertext, key, nonce []byte) ([]byte, error) {
+	return a.Open(ciphertext, key, nonce, nil)
+}
+
+func (a registeredAEAD) Seal(input, key, nonce, additionalData []byte) ([]byte, error) {
+	if err := a.validKey(key); err != nil {
+		return nil, err
+	}
+	return a.spec.seal(input, key, nonce, additionalData, a.pad)
+}
+
+func (a registeredAEAD) Open(ciphertext, key, nonce, additionalData []byte) ([]byte, error) {
+	if err := a.validKey(key); err != nil {
+		return nil, err
+	}
+	return a.spec.open(ciphertext, key, nonce, additionalData, a.pad)
+}
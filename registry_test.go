@@ -0,0 +1,221 @@
+package aes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/colduction/aes/padding"
+)
+
+// suffixPadding is a user-defined padding appending the pad length as its single last byte after zeros.
+type suffixPadding struct{}
+
+func (suffixPadding) String() string { return "LookupTestPadding" }
+
+func (suffixPadding) Pad(b []byte, blocksize int) ([]byte, error) {
+	n := padding.OverheadSize(len(b), blocksize)
+	out := append(bytes.Clone(b), make([]byte, n)...)
+	out[len(out)-1] = byte(n)
+	return out, nil
+}
+
+func (suffixPadding) Unpad(b []byte, blocksize int) ([]byte, error) {
+	lenB := len(b)
+	if lenB == 0 || lenB%blocksize != 0 {
+		return nil, padding.InvalidDataError(lenB)
+	}
+	n := int(b[lenB-1])
+	if n == 0 || n > blocksize {
+		return nil, padding.InvalidDataError(lenB)
+	}
+	return b[:lenB-n], nil
+}
+
+func TestLookupRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	iv := bytes.Repeat([]byte{2}, 32)
+	msg := []byte("resolved by name and back again")
+	for mode, spec := range modes {
+		names := []string{"aes-" + mode}
+		for _, size := range spec.keySizes {
+			names = append(names, fmt.Sprintf("aes-%d-%s", 8*size, mode))
+		}
+		for _, name := range names {
+			m, err := Lookup(name)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if _, ok := m.(AEAD); ok != (spec.seal != nil) {
+				t.Errorf("%s: implements AEAD %v, want %v", name, ok, spec.seal != nil)
+			}
+			again, err := Lookup(m.Name())
+			if err != nil {
+				t.Fatalf("%s: Lookup(%q): %v", name, m.Name(), err)
+			}
+			if again.Name() != m.Name() || again.KeySize() != m.KeySize() || again.IvSize() != m.IvSize() {
+				t.Errorf("%s: %q resolves to %q", name, m.Name(), again.Name())
+			}
+			k := key
+			if m.KeySize() != 0 {
+				k = key[:m.KeySize()]
+			}
+			ct, err := m.Encrypt(msg, k, iv[:m.IvSize()])
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			pt, err := again.Decrypt(ct, k, iv[:m.IvSize()])
+			if err != nil || !bytes.Equal(pt, msg) {
+				t.Errorf("%s: got %x, %v, want %x", name, pt, err, msg)
+			}
+		}
+	}
+}
+
+func TestLookupNames(t *testing.T) {
+	for _, tt := range []struct {
+		name, want string
+		keySize    int
+	}{
+		{"aes-256-gcm", "aes-256-gcm/NoPadding", 32},
+		{"AES-128-CBC", "aes-128-cbc/PKCS7Padding", 16},
+		{"aes-cbc", "aes-cbc/PKCS7Padding", 0},
+		{"aes-128-cbc/pkcs7", "aes-128-cbc/PKCS7Padding", 16},
+		{"aes-192-ctr/iso7816", "aes-192-ctr/ISO7816Padding", 24},
+		{"aes-256-pcbc/x923", "aes-256-pcbc/X923Padding", 32},
+		{"aes-256-ecb/NoPadding", "aes-256-ecb/NoPadding", 32},
+		{"aes-128-cfb128", "aes-128-cfb/NoPadding", 16},
+		{"aes-gcmsiv", "aes-gcm-siv/NoPadding", 0},
+		{"AES/CBC/PKCS5Padding", "aes-cbc/PKCS5Padding", 0},
+		{"AES_256/GCM/NoPadding", "aes-256-gcm/NoPadding", 32},
+		{"AES/CFB8/NoPadding", "aes-cfb8/NoPadding", 0},
+		{"  aes-128-ofb  ", "aes-128-ofb/NoPadding", 16},
+	} {
+		m, err := Lookup(tt.name)
+		if err != nil {
+			t.Errorf("%q: %v", tt.name, err)
+			continue
+		}
+		if got := m.Name(); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.name, got, tt.want)
+		}
+		if got := m.KeySize(); got != tt.keySize {
+			t.Errorf("%q: got key size %d, want %d", tt.name, got, tt.keySize)
+		}
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	for _, name := range []string{
+		"",
+		"aes",
+		"cbc",
+		"des-128-cbc",
+		"aes-128-foo",
+		"aes-100-cbc",
+		"aes-512-cbc",
+		"aes-192-gcm-siv",
+		"aes-128-cbc/bogus",
+		"AES/CBC",
+		"AES/CBC/PKCS5Padding/extra",
+		"AES_100/CBC/NoPadding",
+		"AES/FOO/NoPadding",
+		"aes-siv",
+		"aes-128-kw",
+		"aes-256-xts",
+		"aes-128-cbc-cts",
+	} {
+		_, err := Lookup(name)
+		var target AlgorithmError
+		if !errors.As(err, &target) || string(target) != name {
+			t.Errorf("%q: got %v, want AlgorithmError(%q)", name, err, name)
+		}
+	}
+}
+
+func TestLookupKeySize(t *testing.T) {
+	m, err := Lookup("aes-128-cbc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := bytes.Repeat([]byte{1}, 32)
+	iv := make([]byte, m.IvSize())
+	if _, err := m.Encrypt([]byte("msg"), key, iv); err != KeySizeError(32) {
+		t.Errorf("Encrypt: got %v, want %v", err, KeySizeError(32))
+	}
+	if _, err := m.Decrypt(make([]byte, 16), key, iv); err != KeySizeError(32) {
+		t.Errorf("Decrypt: got %v, want %v", err, KeySizeError(32))
+	}
+}
+
+func TestLookupAEAD(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	msg := []byte("authenticated by name")
+	ad := []byte("header")
+	for _, name := range []string{"aes-128-ccm", "aes-128-eax", "aes-128-gcm", "aes-128-gcm-siv", "aes-128-ocb"} {
+		m, err := Lookup(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		a := m.(AEAD)
+		nonce := bytes.Repeat([]byte{2}, a.IvSize())
+		ct, err := a.Seal(msg, key, nonce, ad)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(ct) != len(msg)+a.Overhead() {
+			t.Errorf("%s: got %d bytes, want %d", name, len(ct), len(msg)+a.Overhead())
+		}
+		if pt, err := a.Open(ct, key, nonce, ad); err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%s: got %x, %v, want %x", name, pt, err, msg)
+		}
+		if _, err := a.Open(ct, key, nonce, []byte("Header")); err == nil {
+			t.Errorf("%s: opened with the wrong additional data", name)
+		}
+		if _, err := a.Open(ct, key, nonce, nil); err == nil {
+			t.Errorf("%s: opened without the additional data", name)
+		}
+	}
+}
+
+// suffixPaddingErr registers suffixPadding once per test binary, so that the tests survive -count.
+var suffixPaddingErr = padding.Register(suffixPadding{}, "lookup-test")
+
+func TestLookupUserPadding(t *testing.T) {
+	if suffixPaddingErr != nil {
+		t.Fatal(suffixPaddingErr)
+	}
+	for _, name := range []string{"LookupTestPadding", "lookuptest", "LOOKUP-TEST"} {
+		p, err := LookupPadding(name)
+		if err != nil || p != (suffixPadding{}) {
+			t.Errorf("%q: got %v, %v, want %v", name, p, err, suffixPadding{})
+		}
+	}
+	key := bytes.Repeat([]byte{1}, 16)
+	iv := bytes.Repeat([]byte{2}, 16)
+	msg := []byte("padded by a user-defined padding")
+	want, err := CBC.Encrypt(msg, key, iv, suffixPadding{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ name, want string }{
+		{"aes-128-cbc/lookup-test", "aes-128-cbc/LookupTestPadding"},
+		{"AES/CBC/LookupTestPadding", "aes-cbc/LookupTestPadding"},
+	} {
+		m, err := Lookup(tt.name)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.name, err)
+		}
+		if got := m.Name(); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.name, got, tt.want)
+		}
+		ct, err := m.Encrypt(msg, key, iv)
+		if err != nil || !bytes.Equal(ct, want) {
+			t.Errorf("%q: got %x, %v, want %x", tt.name, ct, err, want)
+		}
+		if pt, err := m.Decrypt(ct, key, iv); err != nil || !bytes.Equal(pt, msg) {
+			t.Errorf("%q: got %x, %v, want %x", tt.name, pt, err, msg)
+		}
+	}
+}
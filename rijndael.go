@@ -0,0 +1,186 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+)
+
+// rijndael is a pure-Go implementation of the Rijndael block cipher for every
+// combination of 128, 192 and 256 bits block and key sizes. It is table-based
+// and not constant time, the AES block size is served by crypto/aes instead.
+type rijndael struct {
+	nb, nr int
+	rk     []byte // round keys, (nr+1)*4*nb bytes
+}
+
+var rijndaelSbox, rijndaelInvSbox = rijndaelSboxes()
+
+func rijndaelSboxes() (sbox, inv [256]byte) {
+	rotl8 := func(x byte, shift int) byte { return x<<shift | x>>(8-shift) }
+	// p walks the multiplicative group by 3 while q walks its inverse by 1/3.
+	p, q := byte(1), byte(1)
+	for {
+		p = p ^ p<<1 ^ (0x1b & -(p >> 7))
+		q ^= q << 1
+		q ^= q << 2
+		q ^= q << 4
+		q ^= 0x09 & -(q >> 7)
+		x := q ^ rotl8(q, 1) ^ rotl8(q, 2) ^ rotl8(q, 3) ^ rotl8(q, 4)
+		sbox[p] = x ^ 0x63
+		if p == 1 {
+			break
+		}
+	}
+	sbox[0] = 0x63
+	for i, s := range sbox {
+		inv[s] = byte(i)
+	}
+	return
+}
+
+func xtime(a byte) byte { return a<<1 ^ (0x1b & -(a >> 7)) }
+
+// gmul multiplies a by b in GF(2^8). It has no branches, but that does not make
+// the cipher constant time since the S-box lookups are indexed by secret data.
+func gmul(a, b byte) (p byte) {
+	for i := 0; i < 8; i++ {
+		p ^= a & -(b & 1)
+		a = xtime(a)
+		b >>= 1
+	}
+	return
+}
+
+// rijndaelShifts returns the ShiftRows offsets of rows 1 to 3 for nb columns.
+func rijndaelShifts(nb int) [4]int {
+	if nb == 8 {
+		return [4]int{0, 1, 3, 4}
+	}
+	return [4]int{0, 1, 2, 3}
+}
+
+// NewRijndael creates a Rijndael cipher.Block with the given key and block size, both of 16, 24 or 32 bytes.
+// A 16 bytes block size returns the crypto/aes implementation. The other block sizes use
+// table lookups indexed by key and data bytes, which are not constant time and may leak
+// through cache timing side channels.
+func NewRijndael(key []byte, blocksize int) (cipher.Block, error) {
+	if err := ValidBlockSize(blocksize); err != nil {
+		return nil, err
+	}
+	if blocksize == stdaes.BlockSize {
+		return stdaes.NewCipher(key)
+	}
+	if err := ValidKeySize(len(key)); err != nil {
+		return nil, err
+	}
+	return newRijndael(key, blocksize), nil
+}
+
+// newRijndael expands key for the pure-Go cipher, key and block sizes must already be valid.
+func newRijndael(key []byte, blocksize int) *rijndael {
+	nb, nk := blocksize/4, len(key)/4
+	r := &rijndael{nb: nb, nr: max(nb, nk) + 6}
+	words := nb * (r.nr + 1)
+	r.rk = make([]byte, 4*words)
+	copy(r.rk, key)
+	rcon := byte(1)
+	for i := nk; i < words; i++ {
+		var t [4]byte
+		copy(t[:], r.rk[4*(i-1):4*i])
+		switch {
+		case i%nk == 0:
+			t = [4]byte{rijndaelSbox[t[1]] ^ rcon, rijndaelSbox[t[2]], rijndaelSbox[t[3]], rijndaelSbox[t[0]]}
+			rcon = xtime(rcon)
+		case nk > 6 && i%nk == 4:
+			t = [4]byte{rijndaelSbox[t[0]], rijndaelSbox[t[1]], rijndaelSbox[t[2]], rijndaelSbox[t[3]]}
+		}
+		for j := 0; j < 4; j++ {
+			r.rk[4*i+j] = r.rk[4*(i-nk)+j] ^ t[j]
+		}
+	}
+	return r
+}
+
+func (r *rijndael) BlockSize() int { return 4 * r.nb }
+
+func (r *rijndael) addRoundKey(state []byte, round int) {
+	bs := 4 * r.nb
+	for i, k := range r.rk[round*bs : (round+1)*bs] {
+		state[i] ^= k
+	}
+}
+
+// shiftRows rotates row i of the column-major state left by shifts[i], or right when inverse is set.
+func (r *rijndael) shiftRows(state []byte, inverse bool) {
+	shifts := rijndaelShifts(r.nb)
+	var row [8]byte
+	for i := 1; i < 4; i++ {
+		for c := 0; c < r.nb; c++ {
+			row[c] = state[i+4*c]
+		}
+		for c := 0; c < r.nb; c++ {
+			if inverse {
+				state[i+4*((c+shifts[i])%r.nb)] = row[c]
+			} else {
+				state[i+4*c] = row[(c+shifts[i])%r.nb]
+			}
+		}
+	}
+}
+
+func (r *rijndael) Encrypt(dst, src []byte) {
+	bs := 4 * r.nb
+	if len(src) < bs || len(dst) < bs {
+		panic("aes: input not full block")
+	}
+	var state [32]byte
+	s := state[:bs]
+	copy(s, src)
+	r.addRoundKey(s, 0)
+	for round := 1; round <= r.nr; round++ {
+		for i := range s {
+			s[i] = rijndaelSbox[s[i]]
+		}
+		r.shiftRows(s, false)
+		if round != r.nr {
+			for c := 0; c < bs; c += 4 {
+				a0, a1, a2, a3 := s[c], s[c+1], s[c+2], s[c+3]
+				t := a0 ^ a1 ^ a2 ^ a3
+				s[c] ^= t ^ xtime(a0^a1)
+				s[c+1] ^= t ^ xtime(a1^a2)
+				s[c+2] ^= t ^ xtime(a2^a3)
+				s[c+3] ^= t ^ xtime(a3^a0)
+			}
+		}
+		r.addRoundKey(s, round)
+	}
+	copy(dst, s)
+}
+
+func (r *rijndael) Decrypt(dst, src []byte) {
+	bs := 4 * r.nb
+	if len(src) < bs || len(dst) < bs {
+		panic("aes: input not full block")
+	}
+	var state [32]byte
+	s := state[:bs]
+	copy(s, src)
+	r.addRoundKey(s, r.nr)
+	for round := r.nr - 1; round >= 0; round-- {
+		r.shiftRows(s, true)
+		for i := range s {
+			s[i] = rijndaelInvSbox[s[i]]
+		}
+		r.addRoundKey(s, round)
+		if round != 0 {
+			for c := 0; c < bs; c += 4 {
+				a0, a1, a2, a3 := s[c], s[c+1], s[c+2], s[c+3]
+				s[c] = gmul(a0, 14) ^ gmul(a1, 11) ^ gmul(a2, 13) ^ gmul(a3, 9)
+				s[c+1] = gmul(a0, 9) ^ gmul(a1, 14) ^ gmul(a2, 11) ^ gmul(a3, 13)
+				s[c+2] = gmul(a0, 13) ^ gmul(a1, 9) ^ gmul(a2, 14) ^ gmul(a3, 11)
+				s[c+3] = gmul(a0, 11) ^ gmul(a1, 13) ^ gmul(a2, 9) ^ gmul(a3, 14)
+			}
+		}
+	}
+	copy(dst, s)
+}
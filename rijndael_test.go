@@ -0,0 +1,68 @@
+package aes
+
+import (
+	"bytes"
+	stdaes "crypto/aes"
+	"testing"
+)
+
+// Reference vectors from Brian Gladman's Rijndael tables, for every block and key size
+// combination. Key and plaintext are prefixes of the strings below.
+var rijndaelTests = []struct {
+	blocksize, keySize int
+	ciphertext         string
+}{
+	{16, 16, "3925841d02dc09fbdc118597196a0b32"},
+	{16, 24, "f9fb29aefc384a250340d833b87ebc00"},
+	{16, 32, "1a6e6c2c662e7da6501ffb62bc9e93f3"},
+	{24, 16, "b24d275489e82bb8f7375e0d5fcdb1f481757c538b65148a"},
+	{24, 24, "725ae43b5f3161de806a7c93e0bca93c967ec1ae1b71e1cf"},
+	{24, 32, "0ebacf199e3315c2e34b24fcc7c46ef4388aa475d66c194c"},
+	{32, 16, "7d15479076b69a46ffb3b3beae97ad8313f622f67fedb487de9f06b9ed9c8f19"},
+	{32, 24, "5d7101727bb25781bf6715b0e6955282b9610e23a43c2eb062699f0ebf5887b2"},
+	{32, 32, "a49406115dfb30a40418aafa4869b7c6a886ff31602a7dd19c889dc64f7e4e7a"},
+}
+
+func TestRijndaelVectors(t *testing.T) {
+	key := fromHex(t, "2b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfe")
+	plaintext := fromHex(t, "3243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c8")
+	for _, tt := range rijndaelTests {
+		pt, want := plaintext[:tt.blocksize], fromHex(t, tt.ciphertext)
+		// The 16 bytes block size is checked on the pure-Go cipher as well as crypto/aes.
+		block, err := NewRijndael(key[:tt.keySize], tt.blocksize)
+		if err != nil {
+			t.Fatalf("block %d, key %d: %v", tt.blocksize, tt.keySize, err)
+		}
+		for _, b := range []interface {
+			Encrypt(dst, src []byte)
+			Decrypt(dst, src []byte)
+		}{block, newRijndael(key[:tt.keySize], tt.blocksize)} {
+			ct := make([]byte, tt.blocksize)
+			b.Encrypt(ct, pt)
+			if !bytes.Equal(ct, want) {
+				t.Errorf("block %d, key %d: got %x, want %x", tt.blocksize, tt.keySize, ct, want)
+			}
+			b.Decrypt(ct, ct)
+			if !bytes.Equal(ct, pt) {
+				t.Errorf("block %d, key %d: decrypt got %x", tt.blocksize, tt.keySize, ct)
+			}
+		}
+	}
+}
+
+func TestRijndaelSizes(t *testing.T) {
+	if block, err := NewRijndael(make([]byte, 16), 16); err != nil {
+		t.Error(err)
+	} else if _, ok := block.(*rijndael); ok {
+		t.Error("16 bytes block size did not use crypto/aes")
+	}
+	if _, err := NewRijndael(make([]byte, 16), 20); err == nil {
+		t.Error("20 bytes block size accepted")
+	}
+	if _, err := NewRijndael(make([]byte, 20), 24); err != KeySizeError(20) {
+		t.Errorf("20 bytes key got %v", err)
+	}
+	if _, err := NewRijndael(make([]byte, 20), stdaes.BlockSize); err == nil {
+		t.Error("20 bytes key accepted by crypto/aes")
+	}
+}
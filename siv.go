@@ -0,0 +1,147 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/colduction/aes/padding"
+)
+
+const (
+	sivBlockSize  int = 16
+	sivMaxHeaders int = 126
+)
+
+type SIVHeaderCountError int
+
+func (i SIVHeaderCountError) Error() string {
+	return fmt.Sprintf("aes-siv: too many associated data headers %d, at most 126 are allowed", int(i))
+}
+
+func (siv) ValidKeySize(length int) error {
+	switch length {
+	case 32, 48, 64:
+		return nil
+	}
+	return KeySizeError(length)
+}
+
+func (siv) ValidHeaderCount(count int) error {
+	if count > sivMaxHeaders {
+		return SIVHeaderCountError(count)
+	}
+	return nil
+}
+
+// sivS2V computes the synthetic IV over the associated data headers and the plaintext.
+func sivS2V(v *[sivBlockSize]byte, mac *cmacDigest, plaintext []byte, headers [][]byte) {
+	var d, t [sivBlockSize]byte
+	mac.Write(d[:])
+	mac.Sum(d[:0])
+	for _, h := range headers {
+		gf128Double(&d, &d)
+		mac.Reset()
+		mac.Write(h)
+		mac.Sum(t[:0])
+		subtle.XORBytes(d[:], d[:], t[:])
+	}
+	mac.Reset()
+	if n := len(plaintext); n >= sivBlockSize {
+		mac.Write(plaintext[:n-sivBlockSize])
+		subtle.XORBytes(t[:], plaintext[n-sivBlockSize:], d[:])
+	} else {
+		gf128Double(&d, &d)
+		t = [sivBlockSize]byte{}
+		copy(t[:], plaintext)
+		t[n] = 0x80
+		subtle.XORBytes(t[:], t[:], d[:])
+	}
+	mac.Write(t[:])
+	mac.Sum(v[:0])
+}
+
+// sivCTR XORs src with the CTR keystream started from the synthetic IV with bits 31 and 63 cleared.
+func sivCTR(block cipher.Block, v *[sivBlockSize]byte, dst, src []byte) {
+	q := *v
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+	cipher.NewCTR(block, q[:]).XORKeyStream(dst, src)
+}
+
+// sivCiphers splits key into the S2V and CTR halves.
+func sivCiphers(key []byte) (*cmacDigest, cipher.Block, error) {
+	if err := SIV.ValidKeySize(len(key)); err != nil {
+		return nil, nil, err
+	}
+	macBlock, err := stdaes.NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := stdaes.NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return newCMAC(macBlock, sivBlockSize), block, nil
+}
+
+// Encrypts input deterministically using AES in SIV mode (RFC 5297), the 16 bytes synthetic IV is prepended to the ciphertext.
+// A nonce, if any, is passed as the last additional data header.
+func (siv) Encrypt(input, key []byte, pad padding.Padding, additionalData ...[]byte) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	err := SIV.ValidHeaderCount(len(additionalData))
+	if err != nil {
+		return nil, err
+	}
+	mac, block, err := sivCiphers(key)
+	if err != nil {
+		return nil, err
+	}
+	if pad != nil {
+		if input, err = pad.Pad(input, sivBlockSize); err != nil {
+			return nil, err
+		}
+		lenInput = len(input)
+	}
+	var v [sivBlockSize]byte
+	sivS2V(&v, mac, input, additionalData)
+	ct := make([]byte, sivBlockSize+lenInput)
+	copy(ct, v[:])
+	sivCTR(block, &v, ct[sivBlockSize:], input)
+	return ct, nil
+}
+
+// Decrypts ciphertext using AES in SIV mode (RFC 5297)
+func (siv) Decrypt(ciphertext, key []byte, pad padding.Padding, additionalData ...[]byte) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt < sivBlockSize {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	err := SIV.ValidHeaderCount(len(additionalData))
+	if err != nil {
+		return nil, err
+	}
+	mac, block, err := sivCiphers(key)
+	if err != nil {
+		return nil, err
+	}
+	var v, expected [sivBlockSize]byte
+	copy(v[:], ciphertext)
+	pt := make([]byte, lenCt-sivBlockSize)
+	sivCTR(block, &v, pt, ciphertext[sivBlockSize:])
+	sivS2V(&expected, mac, pt, additionalData)
+	if subtle.ConstantTimeCompare(expected[:], v[:]) != 1 {
+		clear(pt)
+		return nil, ErrAuthentication
+	}
+	if pad != nil {
+		if pt, err = pad.Unpad(pt, sivBlockSize); err != nil {
+			return nil, err
+		}
+	}
+	return pt, nil
+}
@@ -0,0 +1,107 @@
+package aes
+
+import (
+	"bytes"
+	stdaes "crypto/aes"
+	"testing"
+)
+
+// RFC 5297 appendix A.
+var sivTests = []struct {
+	name       string
+	key        string
+	headers    []string
+	plaintext  string
+	ciphertext string
+}{
+	{
+		"A.1 deterministic",
+		"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		[]string{"101112131415161718191a1b1c1d1e1f2021222324252627"},
+		"112233445566778899aabbccddee",
+		"85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c",
+	},
+	{
+		"A.2 nonce-based",
+		"7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f",
+		[]string{
+			"00112233445566778899aabbccddeeffdeaddadadeaddadaffeeddccbbaa99887766554433221100",
+			"102030405060708090a0",
+			"09f911029d74e35bd84156c5635688c0",
+		},
+		"7468697320697320736f6d6520706c61696e7465787420746f20656e6372797074207573696e67205349562d414553",
+		"7bdb6e3b432667eb06f4d14bff2fbd0fcb900f2fddbe404326601965c889bf17dba77ceb094fa663b7a3f748ba8af829ea64ad544a272e9c485b62a3fd5c0d",
+	},
+}
+
+func TestSIVVectors(t *testing.T) {
+	for _, tt := range sivTests {
+		key, pt, want := fromHex(t, tt.key), fromHex(t, tt.plaintext), fromHex(t, tt.ciphertext)
+		var headers [][]byte
+		for _, h := range tt.headers {
+			headers = append(headers, fromHex(t, h))
+		}
+		ct, err := SIV.Encrypt(pt, key, nil, headers...)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !bytes.Equal(ct, want) {
+			t.Errorf("%s: got %x, want %x", tt.name, ct, want)
+		}
+		got, err := SIV.Decrypt(ct, key, nil, headers...)
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("%s: decrypt got %x, %v", tt.name, got, err)
+		}
+		if _, err := SIV.Decrypt(ct, key, nil, headers[:len(headers)-1]...); err != ErrAuthentication {
+			t.Errorf("%s: missing header got %v", tt.name, err)
+		}
+		ct[0] ^= 1
+		if _, err := SIV.Decrypt(ct, key, nil, headers...); err != ErrAuthentication {
+			t.Errorf("%s: tampered IV got %v", tt.name, err)
+		}
+	}
+}
+
+// An empty plaintext is padded with 10* and xored with the doubled D of S2V.
+func TestSIVS2VEmpty(t *testing.T) {
+	key := fromHex(t, "fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0")
+	block, err := stdaes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero, err := CMAC.Sum(make([]byte, sivBlockSize), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d [sivBlockSize]byte
+	copy(d[:], zero)
+	gf128Double(&d, &d)
+	d[0] ^= 0x80
+	want, err := CMAC.Sum(d[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v [sivBlockSize]byte
+	sivS2V(&v, newCMAC(block, sivBlockSize), nil, nil)
+	if !bytes.Equal(v[:], want) {
+		t.Errorf("got %x, want %x", v, want)
+	}
+}
+
+func TestSIVMultipleHeaders(t *testing.T) {
+	key := fromHex(t, sivTests[1].key)
+	headers := [][]byte{[]byte("a"), nil, []byte("c")}
+	ct, err := SIV.Encrypt([]byte("plaintext"), key, nil, headers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The order and the number of headers, even empty ones, are authenticated.
+	for _, other := range [][][]byte{{[]byte("c"), nil, []byte("a")}, {[]byte("a"), []byte("c")}, {[]byte("a"), nil, []byte("c"), nil}} {
+		if _, err := SIV.Decrypt(ct, key, nil, other...); err != ErrAuthentication {
+			t.Errorf("headers %q: got %v", other, err)
+		}
+	}
+	if _, err := SIV.Encrypt([]byte("x"), key, nil, make([][]byte, 127)...); err != SIVHeaderCountError(127) {
+		t.Errorf("127 headers: got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package aes
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+
+	"github.com/colduction/aes/padding"
+)
+
+// streamChunkSize is the size of the reads from the underlying reader of a decryptReader.
+const streamChunkSize int = 32 * 1024
+
+// ErrStreamZeroPadding is returned by the stream constructors for the zero padding, which trims
+// zero bytes beyond the last block that a decrypting reader holds back.
+var ErrStreamZeroPadding = errors.New("aes: zero padding is not supported by streams")
+
+func validStreamPadding(pad padding.Padding) error {
+	if pad == padding.Zero {
+		return ErrStreamZeroPadding
+	}
+	return nil
+}
+
+// encryptWriter encrypts the data written to it with either a block mode or a
+// stream mode, holding back the tail that the padding is applied to on Close.
+type encryptWriter struct {
+	w      io.Writer
+	blocks cipher.BlockMode
+	stream cipher.Stream
+	bs     int
+	pad    padding.Padding
+	buf    []byte
+	n      int
+	err    error
+}
+
+func newEncryptWriter(w io.Writer, blocks cipher.BlockMode, stream cipher.Stream, bs int, pad padding.Padding) *encryptWriter {
+	return &encryptWriter{w: w, blocks: blocks, stream: stream, bs: bs, pad: pad}
+}
+
+func (e *encryptWriter) crypt(b []byte) {
+	if e.blocks != nil {
+		e.blocks.CryptBlocks(b, b)
+	} else {
+		e.stream.XORKeyStream(b, b)
+	}
+}
+
+// flush encrypts and writes the first n bytes of the buffer.
+func (e *encryptWriter) flush(n int) error {
+	if n == 0 {
+		return nil
+	}
+	e.crypt(e.buf[:n])
+	if _, err := e.w.Write(e.buf[:n]); err != nil {
+		return err
+	}
+	e.buf = e.buf[:copy(e.buf, e.buf[n:])]
+	return nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	e.buf = append(e.buf, p...)
+	e.n += len(p)
+	n := len(e.buf)
+	switch {
+	case e.pad != nil:
+		// Keep 1 to bs bytes so the padding computed on Close matches the one-shot padding.
+		n = (n - 1) / e.bs * e.bs
+	case e.blocks != nil:
+		n -= n % e.bs
+	}
+	if e.err = e.flush(n); e.err != nil {
+		return 0, e.err
+	}
+	return len(p), nil
+}
+
+// Close pads and writes the remaining data, it does not close the underlying writer.
+// Closing again returns the error of the first Close, if any.
+func (e *encryptWriter) Close() error {
+	if e.err != nil {
+		if e.err == io.ErrClosedPipe {
+			return nil
+		}
+		return e.err
+	}
+	if e.err = e.close(); e.err != nil {
+		return e.err
+	}
+	e.err = io.ErrClosedPipe
+	return nil
+}
+
+func (e *encryptWriter) close() error {
+	if e.n == 0 {
+		return InvalidDataError(e.n)
+	}
+	if e.pad != nil {
+		// The buffer belongs to the writer, so it is padded within its capacity rather than by an append in Pad.
+		var err error
+		if e.buf, err = padding.PadInPlace(e.pad, e.buf, e.bs); err != nil {
+			return err
+		}
+	}
+	if e.blocks != nil && len(e.buf)%e.bs != 0 {
+		return InvalidDataError(e.n)
+	}
+	return e.flush(len(e.buf))
+}
+
+// decryptReader decrypts the data read from the underlying reader, holding
+// back the last block that the padding is removed from once EOF is reached.
+type decryptReader struct {
+	r      io.Reader
+	blocks cipher.BlockMode
+	stream cipher.Stream
+	bs     int
+	pad    padding.Padding
+	chunk  []byte
+	in     []byte
+	out    []byte
+	n      int
+	err    error
+}
+
+func newDecryptReader(r io.Reader, blocks cipher.BlockMode, stream cipher.Stream, bs int, pad padding.Padding) *decryptReader {
+	return &decryptReader{r: r, blocks: blocks, stream: stream, bs: bs, pad: pad, chunk: make([]byte, streamChunkSize)}
+}
+
+// decrypt decrypts the first n bytes of the read ahead ciphertext into out.
+func (d *decryptReader) decrypt(n int) {
+	d.out = append(d.out[:0], d.in[:n]...)
+	if d.blocks != nil {
+		d.blocks.CryptBlocks(d.out, d.out)
+	} else {
+		d.stream.XORKeyStream(d.out, d.out)
+	}
+	d.in = d.in[:copy(d.in, d.in[n:])]
+}
+
+func (d *decryptReader) fill() {
+	m, err := d.r.Read(d.chunk)
+	d.in = append(d.in, d.chunk[:m]...)
+	d.n += m
+	if err == io.EOF {
+		d.final()
+		return
+	}
+	if err != nil {
+		d.err = err
+		return
+	}
+	n := len(d.in)
+	switch {
+	case d.pad != nil:
+		n = (n - 1) / d.bs * d.bs
+	case d.blocks != nil:
+		n -= n % d.bs
+	}
+	if n > 0 {
+		d.decrypt(n)
+	}
+}
+
+func (d *decryptReader) final() {
+	if d.n == 0 {
+		d.err = InvalidCiphertextError(d.n)
+		return
+	}
+	if d.blocks != nil && len(d.in)%d.bs != 0 {
+		d.err = InvalidCiphertextError(d.n)
+		return
+	}
+	d.decrypt(len(d.in))
+	d.err = io.EOF
+	if d.pad != nil {
+		out, err := d.pad.Unpad(d.out, d.bs)
+		if err != nil {
+			d.out, d.err = nil, err
+			return
+		}
+		d.out = out
+	}
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.fill()
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
@@ -0,0 +1,228 @@
+package aes
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/colduction/aes/padding"
+)
+
+type streamMode struct {
+	name    string
+	encrypt func(input []byte, pad padding.Padding) ([]byte, error)
+	decrypt func(ciphertext []byte, pad padding.Padding) ([]byte, error)
+	writer  func(w io.Writer, pad padding.Padding) (io.WriteCloser, error)
+	reader  func(r io.Reader, pad padding.Padding) (io.Reader, error)
+	// blocks is set for the modes that need a padding or aligned input.
+	blocks bool
+}
+
+func streamModes(key, iv []byte) []streamMode {
+	return []streamMode{
+		{
+			"CBC",
+			func(in []byte, pad padding.Padding) ([]byte, error) { return CBC.Encrypt(in, key, iv, pad) },
+			func(ct []byte, pad padding.Padding) ([]byte, error) { return CBC.Decrypt(ct, key, iv, pad) },
+			func(w io.Writer, pad padding.Padding) (io.WriteCloser, error) {
+				return CBC.NewEncryptWriter(w, key, iv, pad)
+			},
+			func(r io.Reader, pad padding.Padding) (io.Reader, error) {
+				return CBC.NewDecryptReader(r, key, iv, pad)
+			},
+			true,
+		},
+		{
+			"CFB",
+			func(in []byte, pad padding.Padding) ([]byte, error) { return CFB.Encrypt(in, key, iv, pad) },
+			func(ct []byte, pad padding.Padding) ([]byte, error) { return CFB.Decrypt(ct, key, iv, pad) },
+			func(w io.Writer, pad padding.Padding) (io.WriteCloser, error) {
+				return CFB.NewEncryptWriter(w, key, iv, pad)
+			},
+			func(r io.Reader, pad padding.Padding) (io.Reader, error) {
+				return CFB.NewDecryptReader(r, key, iv, pad)
+			},
+			false,
+		},
+		{
+			"CTR",
+			func(in []byte, pad padding.Padding) ([]byte, error) { return CTR.Encrypt(in, key, iv, pad) },
+			func(ct []byte, pad padding.Padding) ([]byte, error) { return CTR.Decrypt(ct, key, iv, pad) },
+			func(w io.Writer, pad padding.Padding) (io.WriteCloser, error) {
+				return CTR.NewEncryptWriter(w, key, iv, pad)
+			},
+			func(r io.Reader, pad padding.Padding) (io.Reader, error) {
+				return CTR.NewDecryptReader(r, key, iv, pad)
+			},
+			false,
+		},
+		{
+			"ECB",
+			func(in []byte, pad padding.Padding) ([]byte, error) { return ECB.Encrypt(in, key, pad) },
+			func(ct []byte, pad padding.Padding) ([]byte, error) { return ECB.Decrypt(ct, key, pad) },
+			func(w io.Writer, pad padding.Padding) (io.WriteCloser, error) {
+				return ECB.NewEncryptWriter(w, key, pad)
+			},
+			func(r io.Reader, pad padding.Padding) (io.Reader, error) { return ECB.NewDecryptReader(r, key, pad) },
+			true,
+		},
+		{
+			"OFB",
+			func(in []byte, pad padding.Padding) ([]byte, error) { return OFB.Encrypt(in, key, iv, pad) },
+			func(ct []byte, pad padding.Padding) ([]byte, error) { return OFB.Decrypt(ct, key, iv, pad) },
+			func(w io.Writer, pad padding.Padding) (io.WriteCloser, error) {
+				return OFB.NewEncryptWriter(w, key, iv, pad)
+			},
+			func(r io.Reader, pad padding.Padding) (io.Reader, error) {
+				return OFB.NewDecryptReader(r, key, iv, pad)
+			},
+			false,
+		},
+	}
+}
+
+// writeChunks writes input to w in chunks of varying sizes.
+func writeChunks(w io.Writer, input []byte) error {
+	for i, n := 0, 1; i < len(input); i, n = i+n, n*3%37+1 {
+		if _, err := w.Write(input[i:min(i+n, len(input))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestStreamMatchesOneShot(t *testing.T) {
+	key := bytes.Repeat([]byte{5}, 16)
+	iv := bytes.Repeat([]byte{6}, 16)
+	input := bytes.Repeat([]byte("stream and one-shot output agree"), 3000)
+	for _, m := range streamModes(key, iv) {
+		for _, pad := range []padding.Padding{nil, padding.PKCS7, padding.X923, padding.ISO7816, padding.Bit} {
+			for _, n := range []int{1, 15, 16, 17, 32, streamChunkSize - 1, streamChunkSize, streamChunkSize + 16, len(input)} {
+				if pad == nil && m.blocks && n%16 != 0 {
+					continue
+				}
+				want, err := m.encrypt(input[:n], pad)
+				if err != nil {
+					t.Fatalf("%s %v %d: %v", m.name, pad, n, err)
+				}
+				var buf bytes.Buffer
+				w, err := m.writer(&buf, pad)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = writeChunks(w, input[:n]); err != nil {
+					t.Fatal(err)
+				}
+				if err = w.Close(); err != nil {
+					t.Fatalf("%s %v %d: close: %v", m.name, pad, n, err)
+				}
+				if !bytes.Equal(buf.Bytes(), want) {
+					t.Errorf("%s %v %d: writer output differs from the one-shot output", m.name, pad, n)
+				}
+				r, err := m.reader(iotest.HalfReader(bytes.NewReader(want)), pad)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := io.ReadAll(r)
+				if err != nil || !bytes.Equal(got, input[:n]) {
+					t.Errorf("%s %v %d: reader got %d bytes, %v", m.name, pad, n, len(got), err)
+				}
+			}
+		}
+	}
+}
+
+func TestStreamZeroPadding(t *testing.T) {
+	for _, m := range streamModes(make([]byte, 16), make([]byte, 16)) {
+		if _, err := m.writer(io.Discard, padding.Zero); err != ErrStreamZeroPadding {
+			t.Errorf("%s writer: got %v", m.name, err)
+		}
+		if _, err := m.reader(bytes.NewReader(nil), padding.Zero); err != ErrStreamZeroPadding {
+			t.Errorf("%s reader: got %v", m.name, err)
+		}
+	}
+}
+
+type failingWriter struct{ err error }
+
+func (f failingWriter) Write([]byte) (int, error) { return 0, f.err }
+
+func TestStreamCloseError(t *testing.T) {
+	key := make([]byte, 16)
+	// Unaligned input without a padding fails on Close.
+	w, err := ECB.NewEncryptWriter(io.Discard, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("unaligned")); err != nil {
+		t.Fatal(err)
+	}
+	first := w.Close()
+	if first != InvalidDataError(9) {
+		t.Fatalf("got %v", first)
+	}
+	if err = w.Close(); err != first {
+		t.Errorf("second Close got %v, want %v", err, first)
+	}
+	// A write error of the final flush is returned again too.
+	errWrite := errors.New("write failed")
+	w, err = CBC.NewEncryptWriter(failingWriter{errWrite}, key, make([]byte, 16), padding.PKCS7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("held back")); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = w.Close(); err != errWrite {
+			t.Errorf("Close #%d got %v", i+1, err)
+		}
+	}
+	if _, err = w.Write([]byte("more")); err != errWrite {
+		t.Errorf("Write after Close got %v", err)
+	}
+	// A successful Close can be repeated.
+	w, _ = CTR.NewEncryptWriter(io.Discard, key, make([]byte, 16), nil)
+	w.Write([]byte("ok"))
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Errorf("second Close got %v", err)
+	}
+}
+
+// TestStreamPaddingCapacity checks that the padding applied on Close does not
+// spill into the spare capacity of the slices given to Write.
+func TestStreamPaddingCapacity(t *testing.T) {
+	key := bytes.Repeat([]byte{5}, 16)
+	iv := bytes.Repeat([]byte{6}, 16)
+	for _, m := range streamModes(key, iv) {
+		for _, pad := range []padding.Padding{padding.PKCS7, padding.X923, padding.ISO7816, padding.Bit} {
+			p := bytes.Repeat([]byte{0xaa}, 64)[:5]
+			want, err := m.encrypt(p, pad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			w, err := m.writer(&buf, pad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = w.Write(p); err != nil {
+				t.Fatal(err)
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("%s %v: got %x, want %x", m.name, pad, buf.Bytes(), want)
+			}
+			if spare := p[:cap(p)]; !bytes.Equal(spare, bytes.Repeat([]byte{0xaa}, 64)) {
+				t.Errorf("%s %v: the spare capacity of the written slice was modified: %x", m.name, pad, spare)
+			}
+		}
+	}
+}
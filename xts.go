@@ -0,0 +1,185 @@
+package aes
+
+import (
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+)
+
+const xtsBlockSize int = 16
+
+type XTSSectorSizeError int
+
+func (i XTSSectorSizeError) Error() string {
+	return fmt.Sprintf("aes-xts: invalid sector size %d, it must be at least 16 bytes", int(i))
+}
+
+func (xts) ValidKeySize(length int) error {
+	switch length {
+	case 32, 64:
+		return nil
+	}
+	return KeySizeError(length)
+}
+
+func (xts) ValidSectorSize(length int) error {
+	if length < xtsBlockSize {
+		return XTSSectorSizeError(length)
+	}
+	return nil
+}
+
+// xtsCiphers splits the double-length key into the data and tweak ciphers.
+func xtsCiphers(key []byte) (cipher.Block, cipher.Block, error) {
+	if err := XTS.ValidKeySize(len(key)); err != nil {
+		return nil, nil, err
+	}
+	k1, err := stdaes.NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, nil, err
+	}
+	k2, err := stdaes.NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return k1, k2, nil
+}
+
+// xtsMulAlpha multiplies the tweak by the primitive element alpha in GF(2^128)
+// using the little-endian convention of IEEE 1619, in constant time.
+func xtsMulAlpha(t *[xtsBlockSize]byte) {
+	carry := t[xtsBlockSize-1] >> 7
+	for i := xtsBlockSize - 1; i > 0; i-- {
+		t[i] = t[i]<<1 | t[i-1]>>7
+	}
+	t[0] = t[0]<<1 ^ (cmacRb & -carry)
+}
+
+// xtsBlock encrypts or decrypts a single block under the tweak t.
+func xtsBlock(k1 cipher.Block, t *[xtsBlockSize]byte, dst, src []byte, encrypt bool) {
+	var x [xtsBlockSize]byte
+	subtle.XORBytes(x[:], src[:xtsBlockSize], t[:])
+	if encrypt {
+		k1.Encrypt(x[:], x[:])
+	} else {
+		k1.Decrypt(x[:], x[:])
+	}
+	subtle.XORBytes(dst[:xtsBlockSize], x[:], t[:])
+}
+
+// xtsSector encrypts or decrypts one data unit, using ciphertext stealing for a trailing partial block.
+func xtsSector(k1, k2 cipher.Block, dst, src []byte, sectorNum uint64, encrypt bool) {
+	var t [xtsBlockSize]byte
+	binary.LittleEndian.PutUint64(t[:8], sectorNum)
+	k2.Encrypt(t[:], t[:])
+	r := len(src) % xtsBlockSize
+	full := len(src) - r
+	if r != 0 {
+		// The last full block is processed together with the partial one.
+		full -= xtsBlockSize
+	}
+	for i := 0; i < full; i += xtsBlockSize {
+		xtsBlock(k1, &t, dst[i:], src[i:], encrypt)
+		xtsMulAlpha(&t)
+	}
+	if r == 0 {
+		return
+	}
+	var cc, pp [xtsBlockSize]byte
+	last := src[full+xtsBlockSize:]
+	if encrypt {
+		xtsBlock(k1, &t, cc[:], src[full:], true)
+		xtsMulAlpha(&t)
+		copy(pp[:], last)
+		copy(pp[r:], cc[r:])
+		copy(dst[full+xtsBlockSize:], cc[:r])
+		xtsBlock(k1, &t, dst[full:], pp[:], true)
+		return
+	}
+	// Decryption of the last full block uses the tweak of the partial one.
+	next := t
+	xtsMulAlpha(&next)
+	xtsBlock(k1, &next, pp[:], src[full:], false)
+	copy(cc[:], last)
+	copy(cc[r:], pp[r:])
+	copy(dst[full+xtsBlockSize:], pp[:r])
+	xtsBlock(k1, &t, dst[full:], cc[:], false)
+}
+
+// Encrypts a single sector using AES in XTS mode, the key holds both the data and tweak keys (32 or 64 bytes)
+// and sectorNum is encoded as a little-endian tweak
+func (xts) Encrypt(input, key []byte, sectorNum uint64) ([]byte, error) {
+	lenInput := len(input)
+	if lenInput == 0 {
+		return nil, InvalidDataError(lenInput)
+	}
+	if err := XTS.ValidSectorSize(lenInput); err != nil {
+		return nil, err
+	}
+	k1, k2, err := xtsCiphers(key)
+	if err != nil {
+		return nil, err
+	}
+	ct := make([]byte, lenInput)
+	xtsSector(k1, k2, ct, input, sectorNum, true)
+	return ct, nil
+}
+
+// Decrypts a single sector using AES in XTS mode
+func (xts) Decrypt(ciphertext, key []byte, sectorNum uint64) ([]byte, error) {
+	lenCt := len(ciphertext)
+	if lenCt == 0 {
+		return nil, InvalidCiphertextError(lenCt)
+	}
+	if err := XTS.ValidSectorSize(lenCt); err != nil {
+		return nil, err
+	}
+	k1, k2, err := xtsCiphers(key)
+	if err != nil {
+		return nil, err
+	}
+	pt := make([]byte, lenCt)
+	xtsSector(k1, k2, pt, ciphertext, sectorNum, false)
+	return pt, nil
+}
+
+// Encrypts input split into sectors of sectorSize bytes numbered from firstSector using AES in XTS mode,
+// the last sector may be shorter but not below 16 bytes
+func (xts) EncryptSectors(input, key []byte, sectorSize int, firstSector uint64) ([]byte, error) {
+	return xtsSectors(input, key, sectorSize, firstSector, true)
+}
+
+// Decrypts ciphertext split into sectors of sectorSize bytes numbered from firstSector using AES in XTS mode
+func (xts) DecryptSectors(ciphertext, key []byte, sectorSize int, firstSector uint64) ([]byte, error) {
+	return xtsSectors(ciphertext, key, sectorSize, firstSector, false)
+}
+
+func xtsSectors(src, key []byte, sectorSize int, firstSector uint64, encrypt bool) ([]byte, error) {
+	lenSrc := len(src)
+	if lenSrc == 0 {
+		if encrypt {
+			return nil, InvalidDataError(lenSrc)
+		}
+		return nil, InvalidCiphertextError(lenSrc)
+	}
+	if err := XTS.ValidSectorSize(sectorSize); err != nil {
+		return nil, err
+	}
+	if r := lenSrc % sectorSize; r != 0 {
+		if err := XTS.ValidSectorSize(r); err != nil {
+			return nil, err
+		}
+	}
+	k1, k2, err := xtsCiphers(key)
+	if err != nil {
+		return nil, err
+	}
+	dst := make([]byte, lenSrc)
+	for s, sector := 0, firstSector; s < lenSrc; s, sector = s+sectorSize, sector+1 {
+		e := min(s+sectorSize, lenSrc)
+		xtsSector(k1, k2, dst[s:e], src[s:e], sector, encrypt)
+	}
+	return dst, nil
+}
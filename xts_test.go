@@ -0,0 +1,105 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+// IEEE 1619-2007 appendix B. The standard lists the data unit sequence number
+// as little-endian bytes, so 9a78563412 is sector 0x123456789a.
+var xtsTests = []struct {
+	key        string
+	sector     uint64
+	plaintext  string
+	ciphertext string
+}{
+	// Vectors 1 to 3.
+	{
+		"00000000000000000000000000000000" + "00000000000000000000000000000000", 0,
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"917cf69ebd68b2ec9b9fe9a3eadda692cd43d2f59598ed858c02c2652fbf922e",
+	},
+	{
+		"11111111111111111111111111111111" + "22222222222222222222222222222222", 0x3333333333,
+		"4444444444444444444444444444444444444444444444444444444444444444",
+		"c454185e6a16936e39334038acef838bfb186fff7480adc4289382ecd6d394f0",
+	},
+	{
+		"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0" + "22222222222222222222222222222222", 0x3333333333,
+		"4444444444444444444444444444444444444444444444444444444444444444",
+		"af85336b597afc1a900b2eb21ec949d292df4c047e0b21532186a5971a227a89",
+	},
+	// Vectors 15 to 18 exercise ciphertext stealing.
+	{
+		"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0" + "bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f10",
+		"6c1625db4671522d3d7599601de7ca09ed",
+	},
+	{
+		"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0" + "bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f1011",
+		"d069444b7a7e0cab09e24447d24deb1fedbf",
+	},
+	{
+		"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0" + "bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f101112",
+		"e5df1351c0544ba1350b3363cd8ef4beedbf9d",
+	},
+	{
+		"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0" + "bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f10111213",
+		"9d84c813f719aa2c7be3f66171c7c5c2edbf9dac",
+	},
+}
+
+func TestXTSVectors(t *testing.T) {
+	for i, tt := range xtsTests {
+		key, pt, want := fromHex(t, tt.key), fromHex(t, tt.plaintext), fromHex(t, tt.ciphertext)
+		ct, err := XTS.Encrypt(pt, key, tt.sector)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !bytes.Equal(ct, want) {
+			t.Errorf("#%d: got %x, want %x", i, ct, want)
+		}
+		got, err := XTS.Decrypt(ct, key, tt.sector)
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("#%d: decrypt got %x, %v", i, got, err)
+		}
+	}
+}
+
+func TestXTSSectors(t *testing.T) {
+	key := fromHex(t, xtsTests[3].key)
+	const sectorSize = 512
+	// Three full sectors and a trailing one that needs ciphertext stealing.
+	input := bytes.Repeat([]byte("0123456789abcdef"), 100)[:3*sectorSize+30]
+	ct, err := XTS.EncryptSectors(input, key, sectorSize, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for s := 0; s < len(input); s += sectorSize {
+		e := min(s+sectorSize, len(input))
+		want, err := XTS.Encrypt(input[s:e], key, 7+uint64(s/sectorSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(ct[s:e], want) {
+			t.Errorf("sector %d: got %x, want %x", 7+s/sectorSize, ct[s:e], want)
+		}
+	}
+	pt, err := XTS.DecryptSectors(ct, key, sectorSize, 7)
+	if err != nil || !bytes.Equal(pt, input) {
+		t.Errorf("decrypt: %v", err)
+	}
+	// A trailing sector shorter than a block cannot be stolen from.
+	if _, err := XTS.EncryptSectors(input[:sectorSize+15], key, sectorSize, 0); err != XTSSectorSizeError(15) {
+		t.Errorf("short last sector got %v", err)
+	}
+	if _, err := XTS.Encrypt(input[:15], key, 0); err != XTSSectorSizeError(15) {
+		t.Errorf("short sector got %v", err)
+	}
+	if _, err := XTS.Encrypt(input[:32], key[:24], 0); err != KeySizeError(24) {
+		t.Errorf("24-byte key got %v", err)
+	}
+}